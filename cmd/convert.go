@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"issue-man/config"
+	"os"
+)
+
+var (
+	convert *cobra.Command
+
+	// 输出文件，为空时输出至标准输出
+	out string
+)
+
+func init() {
+	// convert
+	convert = &cobra.Command{
+		Use:   "convert <legacy-config>",
+		Short: "转换旧版本的配置文件。",
+		Long:  `将 README 和 instruction.md 中 full_repository_name、flows 格式的旧版本配置文件，转换为当前以 --- 分隔的多段配置。无法转换的内容会输出至标准错误。`,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			data, err := ioutil.ReadFile(args[0])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			legacy, dropped, err := config.ParseLegacy(data)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			repository, comments, warnings := legacy.Convert()
+			warnings = append(dropped, warnings...)
+			values := []interface{}{repository}
+			for _, v := range comments {
+				values = append(values, v)
+			}
+			result, err := config.MarshalDocuments(values...)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			for _, w := range warnings {
+				fmt.Fprintf(os.Stderr, "%s: warning: %s\n", args[0], w)
+			}
+			if out == "" {
+				fmt.Print(string(result))
+				return
+			}
+			if err := ioutil.WriteFile(out, result, 0644); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	// 添加至 root 节点
+	rootCmd.AddCommand(convert)
+
+	// 解析参数
+	convert.PersistentFlags().StringVarP(&out, "output", "o", "", "输出文件，默认输出至标准输出")
+}
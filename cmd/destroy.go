@@ -19,7 +19,7 @@ func init() {
 		Long:  `根据规则，清空任务仓库的内容。`,
 		Run: func(cmd *cobra.Command, args []string) {
 			// 初始化配置初始化服务相关的东西
-			server.Destroy(loadAndInit())
+			server.Destroy(loadAndInit(false))
 		},
 	}
 
@@ -28,5 +28,5 @@ func init() {
 
 	// 解析参数
 	destroyCmd.PersistentFlags().StringVarP(&token, "token", "t", "", "GitHub Person Token.")
-	destroyCmd.PersistentFlags().StringVarP(&c, "config", "c", "", "指定配置文件路径")
+	destroyCmd.PersistentFlags().StringVarP(&c, "config", "c", "", "指定配置文件路径，可以是文件、目录或者通配符")
 }
@@ -1,22 +1,46 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/spf13/cobra"
+	"issue-man/operation"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 )
 
 var (
 	info *cobra.Command
+
+	// 输出格式，可选 text、json
+	output string
+	// 每条 include 规则最多列出的示例文件数量
+	examples int
 )
 
 func init() {
-	// destroy
+	// info
 	info = &cobra.Command{
 		Use:   "info",
 		Short: "DryRun，用于打印配置文件等。",
-		Long:  `DryRun，用于打印配置文件等。`,
+		Long:  `DryRun，打印加载后的配置，包括项目、指令、定时任务及其下次执行时间、include 规则及示例文件、maintainer 和 member 列表、token 的权限和剩余调用次数。`,
 		Run: func(cmd *cobra.Command, args []string) {
 			// 初始化配置初始化服务相关的东西
-			_ = loadAndInit()
+			_ = loadAndInit(true)
+
+			setup := operation.Describe(examples)
+			switch output {
+			case "json":
+				data, _ := json.MarshalIndent(setup, "", "  ")
+				fmt.Println(string(data))
+			case "", "text":
+				printSetup(setup)
+			default:
+				fmt.Printf("unsupported output: %s\n", output)
+				os.Exit(1)
+			}
 		},
 	}
 
@@ -25,5 +49,54 @@ func init() {
 
 	// 解析参数
 	info.PersistentFlags().StringVarP(&token, "token", "t", "", "GitHub Person Token.")
-	info.PersistentFlags().StringVarP(&c, "config", "c", "", "指定配置文件路径")
+	info.PersistentFlags().StringVarP(&c, "config", "c", "", "指定配置文件路径，可以是文件、目录或者通配符")
+	info.PersistentFlags().StringVarP(&output, "output", "o", "text", "输出格式，可选 text、json")
+	info.PersistentFlags().IntVar(&examples, "examples", 3, "每条 include 规则最多列出的示例文件数量，为 0 时不获取源库的文件列表")
+}
+
+// 以表格的形式输出
+func printSetup(setup operation.Setup) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	section := func(title string, header ...string) {
+		_ = w.Flush()
+		fmt.Printf("\n%s\n", title)
+		if len(header) > 0 {
+			fmt.Fprintln(w, strings.Join(header, "\t"))
+		}
+	}
+	join := func(s []string) string {
+		if len(s) == 0 {
+			return "-"
+		}
+		return strings.Join(s, ", ")
+	}
+
+	section("Projects:", "ROLE", "REPOSITORY", "BRANCH", "SITE")
+	for _, p := range setup.Projects {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Role, p.FullName, p.Branch, p.Site)
+	}
+
+	section("Instructions:", "INSTRUCT", "PERMISSIONS", "LABELS", "EFFECT")
+	for _, i := range setup.Instructions {
+		fmt.Fprintf(w, "/%s\t%s\t%s\t%s\n", i.Instruct, join(i.Permissions), join(i.Labels), strings.ReplaceAll(i.Effect, "<br>", "; "))
+	}
+
+	section("Schedules:", "NAME", "SPEC", "NEXT", "IN", "LABELS", "DRY-RUN")
+	for _, s := range setup.Schedules {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%t\n", s.Name, s.Spec, s.Next.Format(time.RFC3339), s.In, join(s.Labels), s.DryRun)
+	}
+
+	section("Includes:", "PATH", "EXCLUDES", "LABELS", "EXAMPLES")
+	for _, i := range setup.Includes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", i.Path, join(i.Excludes), join(i.Labels), join(i.Examples))
+	}
+
+	section(fmt.Sprintf("Maintainers (%d): %s", len(setup.Maintainers), join(setup.Maintainers)))
+	section(fmt.Sprintf("Members (%d): %s", len(setup.Members), join(setup.Members)))
+	section(fmt.Sprintf("Token scopes: %s", join(setup.TokenScopes)))
+	section(fmt.Sprintf("Rate limit: %d/%d, reset at %s", setup.RateLimit.Remaining, setup.RateLimit.Limit, setup.RateLimit.Reset.Format(time.RFC3339)))
+	for _, e := range setup.Errors {
+		section(fmt.Sprintf("Error: %s", e))
+	}
+	_ = w.Flush()
 }
@@ -20,7 +20,7 @@ func init() {
 		Long:  `根据上游仓库内容和规则，初始化任务仓库内容。`,
 		Run: func(cmd *cobra.Command, args []string) {
 			// 初始化配置初始化服务相关的东西
-			server.Init(loadAndInit())
+			server.Init(loadAndInit(false))
 		},
 	}
 
@@ -29,5 +29,5 @@ func init() {
 
 	// 解析参数
 	initCmd.PersistentFlags().StringVarP(&token, "token", "t", "", "GitHub Person Token.")
-	initCmd.PersistentFlags().StringVarP(&c, "config", "c", "", "指定配置文件路径")
+	initCmd.PersistentFlags().StringVarP(&c, "config", "c", "", "指定配置文件路径，可以是文件、目录或者通配符")
 }
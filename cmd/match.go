@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"issue-man/config"
+	"issue-man/global"
+	"issue-man/tools"
+	"os"
+	"strings"
+)
+
+var matchCmd *cobra.Command
+
+func init() {
+	// match
+	matchCmd = &cobra.Command{
+		Use:   "match <path>...",
+		Short: "检查上游文件匹配的 include。",
+		Long:  `根据配置文件中的 IssueCreate 规则，输出上游文件匹配的 include 及其原因，以及对应 issue 的 title 和 label。`,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			// 仅读取配置文件，不需要 token
+			if c == "" {
+				c = "./config.yaml"
+			}
+			conf, _ := config.Load(c)
+			if conf == nil {
+				fmt.Printf("unable to load config file: %s\n", c)
+				os.Exit(1)
+			}
+			global.Conf = conf
+
+			failed := false
+			for _, file := range args {
+				m, ok := conf.IssueCreate.Explain(file)
+				fmt.Printf("%s:\n", file)
+				for _, v := range m.Trace {
+					fmt.Printf("  %s\n", v)
+				}
+				if !ok {
+					failed = true
+					continue
+				}
+				fmt.Printf("  title: %s\n", *tools.Generate.Title(file, m.Include))
+				fmt.Printf("  labels: %s\n", strings.Join(m.Include.Labels, ", "))
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+
+	// 添加至 root 节点
+	rootCmd.AddCommand(matchCmd)
+
+	// 解析参数
+	matchCmd.PersistentFlags().StringVarP(&c, "config", "c", "", "指定配置文件路径，可以是文件、目录或者通配符")
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"issue-man/operation"
+)
+
+var (
+	reconcile *cobra.Command
+
+	// 仅输出将要做出的改动
+	dryRun bool
+)
+
+func init() {
+	// reconcile
+	reconcile = &cobra.Command{
+		Use:   "reconcile",
+		Short: "修复违反互斥规则的 label。",
+		Long:  `检查所有 open issue 的 label，对于同时拥有同一互斥组内多个 label 的 issue，仅保留最晚添加的 label。`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// 初始化配置初始化服务相关的东西
+			_ = loadAndInit(true)
+
+			data, _ := json.MarshalIndent(operation.Reconcile(dryRun), "", "  ")
+			fmt.Println(string(data))
+		},
+	}
+
+	// 添加至 root 节点
+	rootCmd.AddCommand(reconcile)
+
+	// 解析参数
+	reconcile.PersistentFlags().StringVarP(&token, "token", "t", "", "GitHub Person Token.")
+	reconcile.PersistentFlags().StringVarP(&c, "config", "c", "", "指定配置文件路径，可以是文件、目录或者通配符")
+	reconcile.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "仅输出将要做出的改动")
+}
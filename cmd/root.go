@@ -1,14 +1,10 @@
 package cmd
 
 import (
-	"bytes"
 	"fmt"
-	"github.com/spf13/afero"
-	"gopkg.in/yaml.v2"
 	"issue-man/config"
 	"issue-man/global"
 	"os"
-	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -48,7 +44,20 @@ func Execute() {
 }
 
 // 通用的加载配置文件、初始化 log 组件函数
-func loadAndInit() config.Config {
+// readOnly 为 true 时，只读取存储的快照，可以与 start 启动的服务同时运行
+func loadAndInit(readOnly bool) config.Config {
+	initClient()
+	if err := global.OpenStore(readOnly); err != nil {
+		fmt.Printf("unable to open store: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	// 返回配置对象
+	return *conf
+}
+
+// 加载配置文件，初始化 log 组件及 GitHub Client，不打开存储
+func initClient() {
 	// 如果 token 为空，则尝试从环境变量读取 token
 	if token == "" {
 		token = os.Getenv(IssueManToken)
@@ -58,80 +67,28 @@ func loadAndInit() config.Config {
 			os.Exit(0)
 		}
 	}
+
+	load()
+
+	// 初始化 Client Client，初始化一些全局变量，其中一些信息需调用 Client API
+	global.Init(token, conf)
+}
+
+// 加载并检查配置文件，不需要 token，也不会调用 API
+// 配置文件中有错误时退出
+func load() {
 	// 如果配置文件为空，则自动尝试读取 ./ 目录下的 config，
 	if c == "" {
 		c = "./config.yaml"
 	}
 
 	// 读取配置文件
-	conf = &config.Config{}
-	conf.IssueComments = make([]config.IssueComment, 0)
-	conf.Jobs = make([]config.Job, 0)
-
-	// 读取配置文件
-	data, err := afero.ReadFile(afero.NewOsFs(), c)
-	if err != nil {
-		fmt.Printf("unable to load config file, %v\n", err)
-		os.Exit(1)
+	var problems []config.Problem
+	conf, problems = config.Load(c)
+	for _, p := range problems {
+		fmt.Println(p.String())
 	}
-	bf := bytes.NewBuffer(data)
-	// 拆分配置
-	cfgs := strings.Split(bf.String(), "---")
-
-	// 遍历读取
-	for _, v := range cfgs {
-		base := config.Base{}
-		err := yaml.Unmarshal([]byte(v), &base)
-		if err != nil {
-			panic(err.Error())
-		}
-
-		switch base.Kind {
-		// Repository 的配置
-		case "Repository":
-			tmp := config.Repository{}
-			err = yaml.Unmarshal([]byte(v), &tmp)
-			if err != nil {
-				panic(err.Error())
-			}
-			tmp.Base = base
-			conf.Repository = tmp
-		// IssueCreate 的配置
-		case "IssueCreate":
-			tmp := config.IssueCreate{}
-			err = yaml.Unmarshal([]byte(v), &tmp)
-			if err != nil {
-				panic(err.Error())
-			}
-			tmp.Base = base
-			conf.IssueCreate = tmp
-		// IssueComment 的配置
-		case "IssueComment":
-			tmp := config.IssueComment{}
-			err = yaml.Unmarshal([]byte(v), &tmp)
-			if err != nil {
-				panic(err.Error())
-			}
-			tmp.Base = base
-			conf.IssueComments = append(conf.IssueComments, tmp)
-		// Job 的配置
-		case "Job":
-			tmp := config.Job{}
-			err = yaml.Unmarshal([]byte(v), &tmp)
-			if err != nil {
-				panic(err.Error())
-			}
-			tmp.Base = base
-			conf.Jobs = append(conf.Jobs, tmp)
-		// 不支持类型的配置
-		default:
-			fmt.Printf("Unsupport Type: %s\n", base.Kind)
-		}
+	if config.HasError(problems) {
+		os.Exit(1)
 	}
-
-	// 初始化 Client Client，初始化一些全局变量，其中一些信息需调用 Client API
-	global.Init(token, conf)
-
-	// 返回配置对象
-	return *conf
 }
@@ -18,7 +18,7 @@ func init() {
 		Long:  `开始运行 Issue Man。`,
 		Run: func(cmd *cobra.Command, args []string) {
 			// 初始化配置初始化服务相关的东西
-			server.Start(loadAndInit())
+			server.Start(loadAndInit(false))
 		},
 	}
 
@@ -27,5 +27,5 @@ func init() {
 
 	// 解析参数
 	startCmd.PersistentFlags().StringVarP(&token, "token", "t", "", "GitHub Person Token.")
-	startCmd.PersistentFlags().StringVarP(&c, "config", "c", "", "指定配置文件路径")
+	startCmd.PersistentFlags().StringVarP(&c, "config", "c", "", "指定配置文件路径，可以是文件、目录或者通配符")
 }
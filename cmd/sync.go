@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"issue-man/config"
+	"issue-man/global"
+	"issue-man/operation"
+	"issue-man/store"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	syncCmd *cobra.Command
+
+	// 手动同步的范围
+	replay operation.ReplayOptions
+)
+
+func init() {
+	// sync
+	syncCmd = &cobra.Command{
+		Use:   "sync",
+		Short: "手动同步指定的 pr 或 commit 范围。",
+		Long:  `同步指定 pr（--pr）或 commit 范围（--from、--to）涉及的文件，用于补充处理漏掉的 pr，不会修改检测进度。--dry-run 时仅输出将要对 issue 做出的改动。`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if replay.PR == 0 && replay.From == "" {
+				fmt.Println("either --pr or --from is required")
+				os.Exit(1)
+			}
+			// 初始化配置初始化服务相关的东西
+			initClient()
+
+			replay.DryRun = dryRun
+			var report store.SyncReport
+			err := global.OpenStore(dryRun)
+			switch {
+			case errors.Is(err, store.ErrLocked):
+				// 存储被 start 启动的服务占用，由服务执行同步
+				report, err = replayOnServer(replay)
+			case err != nil:
+				fmt.Printf("unable to open store: %s\n", err.Error())
+				os.Exit(1)
+			default:
+				report, err = operation.Replay(replay)
+			}
+			switch output {
+			case "json":
+				data, _ := json.MarshalIndent(report, "", "  ")
+				fmt.Println(string(data))
+			default:
+				printReport(report)
+			}
+			if err != nil {
+				fmt.Printf("sync fail: %s\n", err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	// 添加至 root 节点
+	rootCmd.AddCommand(syncCmd)
+
+	// 解析参数
+	syncCmd.PersistentFlags().StringVarP(&token, "token", "t", "", "GitHub Person Token.")
+	syncCmd.PersistentFlags().StringVarP(&c, "config", "c", "", "指定配置文件路径，可以是文件、目录或者通配符")
+	syncCmd.PersistentFlags().IntVar(&replay.PR, "pr", 0, "需要同步的 pr number")
+	syncCmd.PersistentFlags().StringVar(&replay.From, "from", "", "同步该 commit 之后的 commit，不包括该 commit")
+	syncCmd.PersistentFlags().StringVar(&replay.To, "to", "", "同步至该 commit 或分支，默认为 source.branch")
+	syncCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "仅输出将要做出的改动")
+	syncCmd.PersistentFlags().StringVarP(&output, "output", "o", "text", "输出格式，可选 text、json")
+}
+
+// 调用 start 启动的服务的 /api/v1/sync 接口执行同步
+func replayOnServer(opt operation.ReplayOptions) (store.SyncReport, error) {
+	addr := global.Conf.Repository.Spec.Port
+	if strings.HasPrefix(addr, ":") {
+		addr = "127.0.0.1" + addr
+	}
+	query := url.Values{}
+	if opt.PR != 0 {
+		query.Set("pr", strconv.Itoa(opt.PR))
+	}
+	if opt.From != "" {
+		query.Set("from", opt.From)
+	}
+	if opt.To != "" {
+		query.Set("to", opt.To)
+	}
+	query.Set("dry-run", strconv.FormatBool(opt.DryRun))
+	query.Set("token", strconv.FormatInt(time.Now().Unix(), 10))
+
+	result := struct {
+		Status string           `json:"status"`
+		Cause  string           `json:"cause"`
+		Report store.SyncReport `json:"report"`
+	}{}
+	resp, err := http.Get(fmt.Sprintf("http://%s/api/v1/sync?%s", addr, query.Encode()))
+	if err != nil {
+		return result.Report, fmt.Errorf("store is locked and the server is unreachable: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return result.Report, fmt.Errorf("bad response from server, status code:%d: %v", resp.StatusCode, err)
+	}
+	if result.Status != "done" {
+		return result.Report, fmt.Errorf("server: %s %s", result.Status, result.Cause)
+	}
+	return result.Report, nil
+}
+
+// 按 pr 或 commit 输出每个文件的处理结果
+func printReport(report store.SyncReport) {
+	for _, u := range report.Units {
+		name := u.SHA
+		if report.Mode == config.DetectionPR {
+			name = fmt.Sprintf("#%d %s", u.PRNumber, u.SHA)
+		}
+		fmt.Printf("%s: %s\n", name, u.Result)
+		for _, f := range u.Files {
+			filename := f.Filename
+			if f.PreviousFilename != "" {
+				filename = fmt.Sprintf("%s -> %s", f.PreviousFilename, f.Filename)
+			}
+			fmt.Printf("  %s %s: %s", f.Status, filename, f.Result)
+			if f.Error != "" {
+				fmt.Printf(" (%s)", f.Error)
+			}
+			if f.Ignored != "" {
+				fmt.Printf(" (ignored: %s)", f.Ignored)
+			}
+			fmt.Println()
+			if len(f.Plan) > 0 {
+				fmt.Printf("    %s\n", strings.Join(f.Plan, "\n    "))
+			}
+		}
+	}
+}
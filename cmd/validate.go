@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"issue-man/config"
+	"os"
+)
+
+var (
+	validateCmd *cobra.Command
+
+	// 有警告时也返回非 0 的退出码
+	strict bool
+)
+
+func init() {
+	// validate
+	validateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "检查配置文件。",
+		Long:  `检查配置文件，报告问题所在的文件和行号。有错误时以非 0 的退出码退出，可以用于 CI。`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if c == "" {
+				c = "./config.yaml"
+			}
+			_, problems := config.Load(c)
+			for _, p := range problems {
+				fmt.Println(p.String())
+			}
+			if config.HasError(problems) || strict && len(problems) > 0 {
+				os.Exit(1)
+			}
+			fmt.Printf("%s: ok\n", c)
+		},
+	}
+
+	// 添加至 root 节点
+	rootCmd.AddCommand(validateCmd)
+
+	// 解析参数
+	validateCmd.PersistentFlags().StringVarP(&c, "config", "c", "", "指定配置文件路径，可以是文件、目录或者通配符")
+	validateCmd.PersistentFlags().BoolVar(&strict, "strict", false, "有警告时也以非 0 的退出码退出")
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"issue-man/config"
+	"os"
+)
+
+var (
+	workflow *cobra.Command
+
+	// 状态图的格式，可选 mermaid、dot，为空则不输出
+	format string
+)
+
+func init() {
+	// workflow
+	workflow = &cobra.Command{
+		Use:   "workflow",
+		Short: "检查工作流程，并输出状态图。",
+		Long:  `检查配置文件中的工作流程，报告未知的状态、无法到达的状态、转换后处于多个状态等问题，并可以输出 Mermaid 或 Graphviz DOT 格式的状态图。`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// 仅读取配置文件，不需要 token
+			if c == "" {
+				c = "./config.yaml"
+			}
+			conf, _ := config.Load(c)
+			if conf == nil {
+				fmt.Printf("unable to load config file: %s\n", c)
+				os.Exit(1)
+			}
+
+			switch format {
+			case "":
+			case "mermaid":
+				for _, w := range conf.Workflows {
+					fmt.Print(w.Mermaid())
+				}
+			case "dot":
+				for _, w := range conf.Workflows {
+					fmt.Print(w.DOT())
+				}
+			default:
+				fmt.Printf("unsupported format: %s\n", format)
+				os.Exit(1)
+			}
+
+			failed := false
+			for _, w := range conf.Workflows {
+				for _, problem := range w.Validate() {
+					failed = true
+					fmt.Fprintln(os.Stderr, problem)
+				}
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+
+	// 添加至 root 节点
+	rootCmd.AddCommand(workflow)
+
+	// 解析参数
+	workflow.PersistentFlags().StringVarP(&c, "config", "c", "", "指定配置文件路径，可以是文件、目录或者通配符")
+	workflow.PersistentFlags().StringVarP(&format, "format", "f", "", "状态图的格式，可选 mermaid、dot")
+}
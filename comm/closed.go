@@ -0,0 +1,61 @@
+package comm
+
+import (
+	"fmt"
+	"github.com/google/go-github/v30/github"
+	"issue-man/config"
+	"issue-man/global"
+	"issue-man/tools"
+)
+
+// 已关闭的 issue 的状态
+const closed = "closed"
+
+// Resolve 根据 detection.closed 找到文件改动需要同步至的 issue
+// issue 为需要更新的 issue，不存在时为 nil，表示需要创建
+// translated 为已关闭的原 issue，detection.closed 为 update 时不为 nil，此时创建的是关联的 update issue
+// ok 为 false 时，表示 issue 已关闭且无需处理
+func Resolve(existIssues map[string]*github.Issue, title string) (issue, translated *github.Issue, ok bool) {
+	issue = existIssues[title]
+	if issue.GetState() != closed {
+		return issue, nil, true
+	}
+	switch global.Conf.Repository.DetectionClosed() {
+	case config.ClosedIgnore:
+		return nil, nil, false
+	case config.ClosedUpdate:
+		return existIssues[UpdateTitle(title)], issue, true
+	default:
+		return issue, nil, true
+	}
+}
+
+// UpdateTitle 返回原 issue 对应的 update issue 的 title
+func UpdateTitle(title string) string {
+	repository := global.Conf.Repository
+	return fmt.Sprintf(repository.Message("sync.updateTitle", repository.Languages("", nil)...), title)
+}
+
+// 创建关联的 update issue，由原 issue 的负责人跟进
+// 并 comment 原 issue 的链接，以及原 issue 关闭之后上游的改动
+func (f File) createUpdate(include config.Include) (*github.Issue, error) {
+	issue := tools.Generate.NewIssue(include, f.CommitFile.GetFilename())
+	issue.Title = tools.Get.String(UpdateTitle(f.Translated.GetTitle()))
+	if len(f.Translated.Assignees) > 0 {
+		issue.Assignees = tools.Convert.Assignees(f.Translated.Assignees)
+	}
+	newIssue, err := tools.Issue.Create(issue)
+	if err != nil {
+		return nil, err
+	}
+
+	repository := global.Conf.Repository
+	source := repository.Spec.Source
+	langs := repository.Languages("", *issue.Labels)
+	changes := f.diffURL()
+	if base, err := tools.Commit.LastBefore(f.CommitFile.GetFilename(), f.Translated.GetClosedAt()); err == nil && base != "" {
+		changes = fmt.Sprintf("https://github.com/%s/%s/compare/%s...%s", source.Owner, source.Repository, base, f.MergeCommitSHA)
+	}
+	tools.Issue.Comment(newIssue.GetNumber(), fmt.Sprintf(repository.Message("sync.update", langs...), f.Translated.GetNumber(), changes))
+	return newIssue, nil
+}
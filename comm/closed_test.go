@@ -0,0 +1,47 @@
+package comm
+
+import (
+	"github.com/google/go-github/v30/github"
+	"issue-man/config"
+	"issue-man/global"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	issue := func(number int, title, state string) *github.Issue {
+		return &github.Issue{Number: &number, Title: &title, State: &state}
+	}
+	existIssues := map[string]*github.Issue{
+		"open":            issue(1, "open", "open"),
+		"closed":          issue(2, "closed", closed),
+		"linked":          issue(3, "linked", closed),
+		"linked (update)": issue(4, "linked (update)", "open"),
+	}
+
+	tests := []struct {
+		name           string
+		policy         string
+		title          string
+		wantIssue      int
+		wantTranslated int
+		wantOK         bool
+	}{
+		{name: "open", policy: config.ClosedIgnore, title: "open", wantIssue: 1, wantOK: true},
+		{name: "missing", title: "missing", wantOK: true},
+		{name: "reopen by default", title: "closed", wantIssue: 2, wantOK: true},
+		{name: "ignore", policy: config.ClosedIgnore, title: "closed", wantOK: false},
+		{name: "create update issue", policy: config.ClosedUpdate, title: "closed", wantTranslated: 2, wantOK: true},
+		{name: "existing update issue", policy: config.ClosedUpdate, title: "linked", wantIssue: 4, wantTranslated: 3, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			global.Conf = &config.Config{}
+			global.Conf.Repository.Spec.Workspace.Detection.Closed = tt.policy
+			issue, translated, ok := Resolve(existIssues, tt.title)
+			if issue.GetNumber() != tt.wantIssue || translated.GetNumber() != tt.wantTranslated || ok != tt.wantOK {
+				t.Errorf("Resolve() = #%d, #%d, %v, want #%d, #%d, %v",
+					issue.GetNumber(), translated.GetNumber(), ok, tt.wantIssue, tt.wantTranslated, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package comm
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/google/go-github/v30/github"
+	"issue-man/global"
+	"issue-man/tools"
+	"strings"
+	"sync"
+)
+
+// Digest 汇总一次同步检测中每个 issue 涉及的文件变动
+// 检测结束后，每个 issue 只发送一条包含文件列表的 comment
+// comment 以 key（如分支）和检测的起点标识，
+// 从相同的起点再次同步时（如部分失败后重新检测），修改机器人之前发送的 comment，而不是新增 comment
+type Digest struct {
+	key  string
+	from string
+	lock sync.Mutex
+	// issue 的最新内容，以及按处理顺序排列的文件变动
+	issues map[int]*github.Issue
+	files  map[int][]File
+	order  []int
+}
+
+// NewDigest 创建一个 Digest，key 用于区分不同的检测，如检测的分支
+// from 为检测的起点，即检测进度的 commit SHA，同步范围的其余部分可能因上游新的改动而变化
+func NewDigest(key, from string) *Digest {
+	return &Digest{
+		key:    key,
+		from:   from,
+		issues: make(map[int]*github.Issue),
+		files:  make(map[int][]File),
+		order:  make([]int, 0),
+	}
+}
+
+// Add 记录 issue 涉及的文件变动
+// 同一个 commit 中的同一个文件只记录一次
+func (d *Digest) Add(issue *github.Issue, f File) {
+	if issue == nil {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	number := issue.GetNumber()
+	if _, ok := d.issues[number]; !ok {
+		d.order = append(d.order, number)
+	}
+	d.issues[number] = issue
+	for i, v := range d.files[number] {
+		if v.MergeCommitSHA == f.MergeCommitSHA && v.CommitFile.GetFilename() == f.CommitFile.GetFilename() {
+			d.files[number][i] = f
+			return
+		}
+	}
+	d.files[number] = append(d.files[number], f)
+}
+
+// Flush 为每个 issue 发送或修改汇总的 comment
+// 对于不满足要求的 issue（没有 assignee 或 Detection.NeedLabel），不进行 comment
+func (d *Digest) Flush() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	// 只修改机器人发送的 comment，获取失败时发送新的 comment
+	login, _ := tools.Account.Login()
+	for _, number := range d.order {
+		issue := d.issues[number]
+		if !(File{}).commentVerify(issue) {
+			continue
+		}
+		body := d.body(issue)
+
+		comments, err := tools.Issue.ListComments(number)
+		if err != nil {
+			continue
+		}
+		marker := d.marker()
+		edited := false
+		for _, c := range comments {
+			if login != "" && c.GetUser().GetLogin() == login && strings.HasPrefix(c.GetBody(), marker) {
+				_ = tools.Issue.EditComment(c.GetID(), body)
+				edited = true
+				break
+			}
+		}
+		if !edited {
+			tools.Issue.Comment(number, body)
+		}
+	}
+	d.issues = make(map[int]*github.Issue)
+	d.files = make(map[int][]File)
+	d.order = make([]int, 0)
+}
+
+// 用于找到之前发送的 comment，不会显示在 comment 中
+// 只包括 key 和检测的起点，重新检测时范围的终点可能不同，如期间上游合并了新的 pr
+func (d *Digest) marker() string {
+	if d.from == "" {
+		return fmt.Sprintf("<!-- issue-man sync %s -->", d.key)
+	}
+	return fmt.Sprintf("<!-- issue-man sync %s:%s -->", d.key, d.from)
+}
+
+// 汇总的 comment 内容，包括文件、状态、pr、diff 链接及改动行数
+func (d *Digest) body(issue *github.Issue) string {
+	repository := global.Conf.Repository
+	labels := make([]string, 0, len(issue.Labels))
+	for _, v := range issue.Labels {
+		labels = append(labels, v.GetName())
+	}
+	langs := repository.Languages("", labels)
+	source := repository.Spec.Source
+	files := d.files[issue.GetNumber()]
+
+	bf := bytes.Buffer{}
+	bf.WriteString(d.marker())
+	bf.WriteString("\n")
+	bf.WriteString(fmt.Sprintf(repository.Message("sync.summary", langs...), len(files)))
+	bf.WriteString("\n\n")
+	bf.WriteString(repository.Message("sync.table", langs...))
+	bf.WriteString("\n| --- | --- | --- | --- | --- |\n")
+	for _, f := range files {
+		filename := fmt.Sprintf("`%s`", f.CommitFile.GetFilename())
+		if f.CommitFile.GetPreviousFilename() != "" {
+			filename = fmt.Sprintf("`%s` → `%s`", f.CommitFile.GetPreviousFilename(), f.CommitFile.GetFilename())
+		}
+		change := fmt.Sprintf("[%.7s](https://github.com/%s/%s/commit/%s)", f.MergeCommitSHA, source.Owner, source.Repository, f.MergeCommitSHA)
+		if f.PrNumber > 0 {
+			change = fmt.Sprintf("[#%d](%s)", f.PrNumber, f.pullRequestURL())
+		}
+		bf.WriteString(fmt.Sprintf("| %s | %s | %s | [diff](%s) | +%d / -%d |\n",
+			filename,
+			f.CommitFile.GetStatus(),
+			change,
+			f.diffURL(),
+			f.CommitFile.GetAdditions(),
+			f.CommitFile.GetDeletions(),
+		))
+	}
+
+	bf.WriteString("\n")
+	bf.WriteString(repository.Message("sync.assignees", langs...))
+	for _, v := range issue.Assignees {
+		bf.WriteString(fmt.Sprintf("@%s ", v.GetLogin()))
+	}
+	return bf.String()
+}
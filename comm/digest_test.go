@@ -0,0 +1,66 @@
+package comm
+
+import (
+	"github.com/google/go-github/v30/github"
+	"issue-man/config"
+	"issue-man/global"
+	"strings"
+	"testing"
+)
+
+func TestDigest_body(t *testing.T) {
+	global.Conf = &config.Config{}
+	global.Conf.Repository.Spec.Source.Owner = "owner"
+	global.Conf.Repository.Spec.Source.Repository = "repo"
+	number, login := 1, "alice"
+	issue := &github.Issue{Number: &number, Assignees: []*github.User{{Login: &login}}}
+	file := func(pr int, sha, status, filename string, additions, deletions int) File {
+		return File{
+			PrNumber:       pr,
+			MergeCommitSHA: sha,
+			CommitFile:     &github.CommitFile{Status: &status, Filename: &filename, Additions: &additions, Deletions: &deletions},
+		}
+	}
+
+	d := NewDigest("master", "0000000000")
+	d.Add(issue, file(10, "1111111111", MODIFY, "a.md", 1, 2))
+	d.Add(issue, file(0, "2222222222", ADD, "b.md", 3, 0))
+	// 同一个 commit 中的同一个文件只记录一次
+	d.Add(issue, file(10, "1111111111", MODIFY, "a.md", 4, 5))
+
+	body := d.body(issue)
+	lines := strings.Split(body, "\n")
+	want := []string{
+		"<!-- issue-man sync master:0000000000 -->",
+		"上游文件有变动（2 个）：",
+		"",
+		"| 文件 | 状态 | Pull Request / Commit | 差异 | 行数 |",
+		"| --- | --- | --- | --- | --- |",
+		"| `a.md` | modified | [#10](https://github.com/owner/repo/pull/10) | [diff](https://github.com/owner/repo/pull/10/files#diff-",
+		"| `b.md` | added | [2222222](https://github.com/owner/repo/commit/2222222222) | [diff](https://github.com/owner/repo/commit/2222222222#diff-",
+	}
+	for i, w := range want {
+		if i >= len(lines) || !strings.HasPrefix(lines[i], w) {
+			t.Fatalf("line %d = %q, want prefix %q\n%s", i, lines[i], w, body)
+		}
+	}
+	if !strings.HasSuffix(lines[5], "+4 / -5 |") || !strings.HasSuffix(lines[6], "+3 / -0 |") {
+		t.Errorf("unexpected line counts\n%s", body)
+	}
+	if !strings.HasSuffix(body, "@alice ") {
+		t.Errorf("missing assignees\n%s", body)
+	}
+
+	// marker 只与检测的起点有关，从相同的起点重新检测时，即使范围变大也修改之前的 comment
+	other := 2
+	d.Add(&github.Issue{Number: &other}, file(0, "3333333333", MODIFY, "c.md", 1, 1))
+	if got, want := d.marker(), "<!-- issue-man sync master:0000000000 -->"; got != want {
+		t.Errorf("marker() = %q, want %q", got, want)
+	}
+
+	// 指定语言时使用对应的文本
+	global.Conf.Repository.Spec.Language = "en"
+	if lines := strings.Split(d.body(issue), "\n"); lines[1] != "Upstream files changed (2):" {
+		t.Errorf("summary = %q, want english", lines[1])
+	}
+}
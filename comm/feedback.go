@@ -2,6 +2,8 @@ package comm
 
 import (
 	"fmt"
+	"issue-man/config"
+	"issue-man/global"
 	"strconv"
 	"strings"
 )
@@ -22,14 +24,49 @@ type Comment struct {
 	ResetDate  string
 	ReqID      string
 	Assignees  []string
+
+	// 以下字段仅在模板中使用
+	// 当前 issue 及指令的信息，可能为空
+	Info *Info
+	// 未通过的检查及其要求
+	Rule    string
+	Require []string
+}
+
+// NewComment 根据 info 初始化 Comment
+func NewComment(info Info) Comment {
+	return Comment{
+		Login:     info.Login,
+		ReqID:     info.ReqID,
+		Assignees: info.Assignees,
+		Info:      &info,
+	}
+}
+
+// HandText 根据评论人和 issue 的语言选择文本，再做替换
+func (r Comment) HandText(text config.Text) string {
+	return r.HandComment(text.Get(r.Languages()...))
 }
 
-// 这里只对一些关键字做替换
+// Languages 返回语言的查找顺序，见 config.Repository.Languages
+func (r Comment) Languages() []string {
+	var labels []string
+	if r.Info != nil {
+		labels = r.Info.Labels
+	}
+	return global.Conf.Repository.Languages(r.Login, labels)
+}
+
+// 文本中包含 {{ 时，视为 text/template 模板，先执行模板
+// 然后再对一些关键字做替换，以兼容旧的写法
 // 具体的值需要自行计算
 func (r Comment) HandComment(text string) string {
 	if text == "" {
 		return ""
 	}
+	if strings.Contains(text, "{{") {
+		text = r.execute(text)
+	}
 	text = strings.ReplaceAll(text, Commenter, fmt.Sprintf("@%s", r.Login))
 	text = strings.ReplaceAll(text, Count, strconv.Itoa(r.LimitCount))
 	text = strings.ReplaceAll(text, ResetDate, fmt.Sprintf("`%s`", r.ResetDate))
@@ -0,0 +1,87 @@
+package comm
+
+import (
+	"go.uber.org/zap"
+	"io/ioutil"
+	"issue-man/config"
+	"issue-man/global"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestComment_HandComment(t *testing.T) {
+	global.Conf = &config.Config{}
+	global.Sugar = zap.NewNop().Sugar()
+
+	info := Info{
+		Login:       "gorda",
+		ReqID:       "req",
+		IssueNumber: 12,
+		Title:       "docs/intro",
+		Labels:      []string{"kind/page", "status/stale"},
+		Assignees:   []string{"a", "b"},
+		Instruct:    "accept",
+		Args:        []string{"@c", "now"},
+	}
+	hc := NewComment(info)
+	hc.Rule = "CheckLabel"
+	hc.Require = []string{"status/pending"}
+	hc.ResetDate = "2020-06-01"
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "empty", text: "", want: ""},
+		{name: "legacy", text: "@commenter, @req-id, @reset-date, @assignees", want: "@gorda, `req`, `2020-06-01`, @a, @b "},
+		{name: "fields", text: "{{.Commenter}} /{{.Instruct}} #{{.Issue.Number}} {{.Issue.Title}}", want: "gorda /accept #12 docs/intro"},
+		{name: "funcs", text: "{{mention .Assignees}} {{.Issue.Labels | join \",\"}} {{.Args | join \" \"}}", want: "@a, @b kind/page,status/stale @c now"},
+		{name: "conditional", text: "{{if has .Issue.Labels \"status/stale\"}}stale{{else}}fresh{{end}}", want: "stale"},
+		{name: "rule", text: "{{.Rule}}: {{.Require | join \" \"}}", want: "CheckLabel: status/pending"},
+		{name: "mixed", text: "@commenter {{.Issue.Number}}", want: "@gorda 12"},
+		{name: "bad template", text: "{{.Unknown", want: "{{.Unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hc.HandComment(tt.text); got != tt.want {
+				t.Errorf("HandComment() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComment_HandComment_templates(t *testing.T) {
+	global.Sugar = zap.NewNop().Sugar()
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "accept.tmpl")
+	hc := NewComment(Info{Login: "gorda"})
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "load", content: `{{define "accept"}}@{{.Commenter}} accepted{{end}}`, want: "@gorda accepted"},
+		// 配置被替换后重新加载模板
+		{name: "reload", content: `{{define "accept"}}{{.Commenter | upper}}{{end}}`, want: "GORDA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ioutil.WriteFile(file, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			conf := &config.Config{}
+			conf.Repository.Spec.Templates = []string{filepath.Join(dir, "*.tmpl")}
+			global.Conf = conf
+			if got := hc.HandComment(`{{template "accept" .}}`); got != tt.want {
+				t.Errorf("HandComment() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -15,66 +15,180 @@ type File struct {
 	MergedAt       string
 	MergeCommitSHA string
 	CommitFile     *github.CommitFile
+	// commit message，pr 方式检测时为 pr 的标题，用于判断改动是否可以忽略
+	Message string
+	// 已关闭的原 issue，见 Resolve
+	// 不为空时，需要创建的 issue 为关联的 update issue
+	Translated *github.Issue
+	// 不为空时，文件变动的提示汇总至 Digest，而不是每个文件发送一条 comment
+	Digest *Digest
 }
 
+// 这里的操作指的是文件的操作，取值来自于 GitHub
+// 至于 issue 是否存在，调用何种方法，需要额外判断
+const (
+	ADD    = "added"
+	MODIFY = "modified"
+	RENAME = "renamed"
+	REMOVE = "removed"
+)
+
 // 处理需同步文件
-func (f File) Sync(include config.Include, existIssue, preIssue *github.Issue) {
-	// 这里的操作指的是文件的操作，取值来自于 GitHub
-	// 至于 issue 是否存在，调用何种方法，需要额外判断
-	const (
-		ADD    = "added"
-		MODIFY = "modified"
-		RENAME = "renamed"
-		REMOVE = "removed"
-	)
+// 返回被创建、更新的 issue，以便后续的文件使用最新的 issue 内容
+// 调用 API 失败时返回错误，以便记录并在下次检测时重试
+func (f File) Sync(include config.Include, existIssue, preIssue *github.Issue) ([]*github.Issue, error) {
 	switch *f.CommitFile.Status {
 	// 更新 issue，不存在则创建 issue
 	case ADD, MODIFY:
 		// 更新 issue
 		if existIssue != nil {
-			f.update(existIssue)
-		} else {
-			// 创建 issue
-			f.create(include)
+			return issues(f.update(existIssue))
 		}
+		// 创建 issue
+		return issues(f.create(include))
 	// 重命名/移动文件
 	case RENAME:
-		f.rename(include, existIssue, preIssue)
+		return f.rename(include, existIssue, preIssue)
 	// 移除文件
 	case REMOVE:
-		f.remove(existIssue)
+		return issues(f.remove(existIssue))
 	default:
 		global.Sugar.Warnw("unknown status",
 			"file", f,
 			"status", *f.CommitFile.Status)
+		return nil, nil
 	}
 }
 
+// Plan 返回 Sync 将要对 issue 做出的改动，不会调用 API
+// 处理逻辑与 Sync 保持一致，用于 dry-run
+func (f File) Plan(include config.Include, existIssue, preIssue *github.Issue) []string {
+	filename := f.CommitFile.GetFilename()
+	previous := f.CommitFile.GetPreviousFilename()
+	create := fmt.Sprintf("create issue %q with %s", *tools.Generate.Title(filename, include), filename)
+	if f.Translated != nil {
+		create = fmt.Sprintf("create issue %q with %s, linked to #%d", UpdateTitle(f.Translated.GetTitle()), filename, f.Translated.GetNumber())
+	}
+	switch f.CommitFile.GetStatus() {
+	case ADD, MODIFY:
+		if existIssue != nil {
+			// 可以忽略的改动不会重新打开 issue
+			if ignored := f.Ignored(); ignored != "" {
+				return []string{fmt.Sprintf("update #%d: %s %s (ignored: %s, recorded in body, no reopen, label or comment)", existIssue.GetNumber(), f.CommitFile.GetStatus(), filename, ignored)}
+			}
+			return []string{fmt.Sprintf("update %s: %s %s", issueName(existIssue), f.CommitFile.GetStatus(), filename)}
+		}
+		return []string{create}
+	case RENAME:
+		if existIssue != nil && preIssue != nil && existIssue.GetNumber() == preIssue.GetNumber() {
+			return []string{fmt.Sprintf("update %s: rename %s to %s", issueName(existIssue), previous, filename)}
+		}
+		plan := []string{create}
+		if existIssue != nil {
+			plan[0] = fmt.Sprintf("update %s: add %s", issueName(existIssue), filename)
+		}
+		if preIssue != nil {
+			plan = append(plan, fmt.Sprintf("update %s: remove %s", issueName(preIssue), previous))
+		}
+		return plan
+	case REMOVE:
+		if existIssue == nil {
+			return nil
+		}
+		return []string{fmt.Sprintf("update %s: remove %s", issueName(existIssue), filename)}
+	default:
+		return []string{fmt.Sprintf("unknown status %q, nothing to do", f.CommitFile.GetStatus())}
+	}
+}
+
+// dry-run 时，将要创建的 issue 没有 number
+func issueName(issue *github.Issue) string {
+	if issue.GetNumber() == 0 {
+		return fmt.Sprintf("issue %q (to be created)", issue.GetTitle())
+	}
+	if issue.GetState() == closed {
+		return fmt.Sprintf("#%d (reopen)", issue.GetNumber())
+	}
+	return fmt.Sprintf("#%d", issue.GetNumber())
+}
+
+// 失败时也会返回已完成改动的 issue
+func issues(issue *github.Issue, err error) ([]*github.Issue, error) {
+	if issue == nil {
+		return nil, err
+	}
+	return []*github.Issue{issue}, err
+}
+
 // 创建 issue，无 comment
-func (f File) create(include config.Include) {
+func (f File) create(include config.Include) (*github.Issue, error) {
+	if f.Translated != nil {
+		return f.createUpdate(include)
+	}
 	// 创建通用 issue，按照 create 相关配置初始化、分级
-	_, _ = tools.Issue.Create(tools.Generate.NewIssue(include, *f.CommitFile.Filename))
 	// 无需 comment
+	return tools.Issue.Create(tools.Generate.NewIssue(include, *f.CommitFile.Filename))
+}
+
+// Ignored 返回文件改动被忽略的原因，不可忽略时返回空字符串
+// 只有 modified 的文件可以被忽略，见 config.Significance
+func (f File) Ignored() string {
+	if f.CommitFile.GetStatus() != MODIFY {
+		return ""
+	}
+	return global.Conf.Repository.Spec.Workspace.Detection.Ignore.Ignore(
+		f.Message,
+		f.CommitFile.GetPatch(),
+		f.CommitFile.GetAdditions(),
+		f.CommitFile.GetDeletions(),
+	)
 }
 
 // 更新 issue，并 comment
+// 对于可以忽略的改动，只更新 issue
 func (f File) update(existIssue *github.Issue) (*github.Issue, error) {
 	// 更新
 	issue := tools.Generate.UpdateIssue(false, f.CommitFile.GetFilename(), *existIssue)
+	ignored := f.Ignored()
+	// 在 body 中记录被忽略的改动及原因
+	if ignored != "" {
+		issue.Body = tools.Get.String(tools.Generate.IgnoredRecord(issue.GetBody(), f.CommitFile.GetFilename(), f.MergeCommitSHA, ignored))
+	}
+	// 已关闭的 issue，重新打开
+	// 可以忽略的改动只记录在 body 中，issue 保持关闭
+	reopen := existIssue.GetState() == closed && ignored == ""
+	if reopen {
+		issue.State = tools.Get.String("open")
+	}
 	// 对于有 assigner 的 issue，添加和移除一些 label
 	// 反之，不改动 issue label
-	if len(existIssue.Assignees) > 0 {
+	if len(existIssue.Assignees) > 0 && ignored == "" {
 		issue.Labels = tools.Convert.SliceAdd(issue.Labels, global.Conf.Repository.Spec.Workspace.Detection.AddLabel...)
 		issue.Labels = tools.Convert.SliceRemove(issue.Labels, global.Conf.Repository.Spec.Workspace.Detection.RemoveLabel...)
+		// 移除与新增 label 互斥的 label
+		issue.Labels = tools.Get.Strings(global.Conf.Repository.ExclusiveLabels(*issue.Labels, global.Conf.Repository.Spec.Workspace.Detection.AddLabel...))
 	}
 
 	updatedIssue, err := tools.Issue.EditByIssueRequest(existIssue.GetNumber(), issue)
 	if err != nil {
 		return nil, err
 	}
+	tools.Issue.Index(updatedIssue)
+	if reopen {
+		repository := global.Conf.Repository
+		tools.Issue.Comment(updatedIssue.GetNumber(), repository.Message("sync.reopened", repository.Languages("", *tools.Convert.Label(updatedIssue.Labels))...))
+	}
 
 	// comment
-	_ = f.comment(updatedIssue)
+	if ignored != "" {
+		global.Sugar.Infow("ignore upstream change",
+			"file", f.CommitFile.GetFilename(),
+			"sha", f.MergeCommitSHA,
+			"reason", ignored,
+		)
+		return updatedIssue, nil
+	}
+	f.notify(updatedIssue)
 
 	return updatedIssue, nil
 }
@@ -93,36 +207,44 @@ func (f File) comment(issue *github.Issue) error {
 	if !f.commentVerify(issue) {
 		return nil
 	}
-	bf := bytes.Buffer{}
-	bf.WriteString(fmt.Sprintf("Pull Request: https://github.com/%s/%s/pull/%d",
-		global.Conf.Repository.Spec.Source.Owner,
-		global.Conf.Repository.Spec.Source.Repository,
-		f.PrNumber))
+	// 根据 issue 的语言 label 和项目配置选择语言
+	repository := global.Conf.Repository
+	langs := repository.Languages("", *tools.Convert.Label(issue.Labels))
+	source := repository.Spec.Source
 
-	bf.WriteString(fmt.Sprintf("\n\nDiff: https://github.com/%s/%s/pull/%d/files#diff-%s",
-		global.Conf.Repository.Spec.Source.Owner,
-		global.Conf.Repository.Spec.Source.Repository,
-		f.PrNumber, fmt.Sprintf("%x", md5.Sum([]byte(f.CommitFile.GetFilename())))))
+	bf := bytes.Buffer{}
+	if f.PrNumber > 0 {
+		bf.WriteString(fmt.Sprintf(repository.Message("sync.pullRequest", langs...), f.pullRequestURL()))
+		bf.WriteString("\n\n")
+	}
+	bf.WriteString(fmt.Sprintf(repository.Message("sync.diff", langs...), f.diffURL()))
 
-	bf.WriteString(fmt.Sprintf("\n\nCommit SHA: [%s](https://github.com/%s/%s/blob/%s/%s)",
-		f.MergeCommitSHA,
-		global.Conf.Repository.Spec.Source.Owner,
-		global.Conf.Repository.Spec.Source.Repository,
-		f.MergeCommitSHA,
-		f.CommitFile.GetFilename(),
-	))
+	bf.WriteString("\n\n")
+	bf.WriteString(fmt.Sprintf(repository.Message("sync.commit", langs...),
+		fmt.Sprintf("[%s](https://github.com/%s/%s/blob/%s/%s)",
+			f.MergeCommitSHA,
+			source.Owner,
+			source.Repository,
+			f.MergeCommitSHA,
+			f.CommitFile.GetFilename(),
+		)))
 
-	bf.WriteString(fmt.Sprintf("\n\nMerged At: %s", f.MergedAt))
+	bf.WriteString("\n\n")
+	bf.WriteString(fmt.Sprintf(repository.Message("sync.mergedAt", langs...), f.MergedAt))
 
-	bf.WriteString(fmt.Sprintf("\n\nFilename: %s", f.CommitFile.GetFilename()))
+	bf.WriteString("\n\n")
+	bf.WriteString(fmt.Sprintf(repository.Message("sync.filename", langs...), f.CommitFile.GetFilename()))
 
 	if f.CommitFile.GetPreviousFilename() != "" {
-		bf.WriteString(fmt.Sprintf("\n\nPrevious Filename: %s", f.CommitFile.GetPreviousFilename()))
+		bf.WriteString("\n\n")
+		bf.WriteString(fmt.Sprintf(repository.Message("sync.previousFilename", langs...), f.CommitFile.GetPreviousFilename()))
 	}
 
-	bf.WriteString(fmt.Sprintf("\n\nStatus: %s", f.CommitFile.GetStatus()))
+	bf.WriteString("\n\n")
+	bf.WriteString(fmt.Sprintf(repository.Message("sync.status", langs...), f.CommitFile.GetStatus()))
 
-	bf.WriteString("\n\nAssignees: ")
+	bf.WriteString("\n\n")
+	bf.WriteString(repository.Message("sync.assignees", langs...))
 	for _, v := range issue.Assignees {
 		bf.WriteString(fmt.Sprintf("@%s ", v.GetLogin()))
 	}
@@ -131,62 +253,106 @@ func (f File) comment(issue *github.Issue) error {
 	return nil
 }
 
+// pr 的链接
+func (f File) pullRequestURL() string {
+	source := global.Conf.Repository.Spec.Source
+	return fmt.Sprintf("https://github.com/%s/%s/pull/%d", source.Owner, source.Repository, f.PrNumber)
+}
+
+// 文件改动的链接，直接 push 的 commit 没有对应的 pr，使用 commit 的链接
+func (f File) diffURL() string {
+	source := global.Conf.Repository.Spec.Source
+	anchor := fmt.Sprintf("%x", md5.Sum([]byte(f.CommitFile.GetFilename())))
+	if f.PrNumber > 0 {
+		return fmt.Sprintf("https://github.com/%s/%s/pull/%d/files#diff-%s", source.Owner, source.Repository, f.PrNumber, anchor)
+	}
+	return fmt.Sprintf("https://github.com/%s/%s/commit/%s#diff-%s", source.Owner, source.Repository, f.MergeCommitSHA, anchor)
+}
+
+// 通知 issue 的 assignees 文件有变动
+// 设置了 Digest 时，汇总至 Digest，检测结束后统一发送
+func (f File) notify(issue *github.Issue) {
+	if f.Digest != nil {
+		f.Digest.Add(issue, f)
+		return
+	}
+	_ = f.comment(issue)
+}
+
 // 删除 issue 中的文件
-func (f File) remove(issue *github.Issue) {
+// 对于 removed 文件，删除的是文件本身，对于 renamed 文件，删除的是原文件
+func (f File) remove(issue *github.Issue) (*github.Issue, error) {
 	if issue == nil {
 		global.Sugar.Warnw("remove exist file issue",
 			"status", "has no match issue",
 			"file", f)
-		return
+		return nil, nil
+	}
+	filename := f.CommitFile.GetPreviousFilename()
+	if filename == "" {
+		filename = f.CommitFile.GetFilename()
 	}
-	updatedIssue, err := tools.Issue.EditByIssueRequest(issue.GetNumber(), tools.Generate.UpdateIssue(true, f.CommitFile.GetPreviousFilename(), *issue))
+	updatedIssue, err := tools.Issue.EditByIssueRequest(issue.GetNumber(), tools.Generate.UpdateIssue(true, filename, *issue))
 	if err != nil {
-		return
+		return nil, err
 	}
+	tools.Issue.Index(updatedIssue, filename)
 
 	// comment
-	_ = f.comment(updatedIssue)
+	f.notify(updatedIssue)
+	return updatedIssue, nil
 }
 
 // 对于 renamed 文件，需要：
 // 1. 更新/创建 新的 issue
 // 2. 在旧的 issue 中移除对应的文件
-func (f File) rename(include config.Include, existIssue, preIssue *github.Issue) {
+func (f File) rename(include config.Include, existIssue, preIssue *github.Issue) ([]*github.Issue, error) {
 	// 更新 issue
 	if existIssue != nil {
 		// preIssue 为空，则仅更新 existIssue
 		// 这种极端情况很难出现
 		if preIssue == nil {
-			f.update(existIssue)
 			global.Sugar.Warnw("renamed file issue",
 				"status", "has no match previous issue",
 				"filename", f.CommitFile.GetFilename(),
 				"previous filename", f.CommitFile.GetPreviousFilename(),
 			)
-			return
+			return issues(f.update(existIssue))
 		}
 		// existIssue 和 preIssue 是同一个 issue
 		if existIssue.GetNumber() == preIssue.GetNumber() {
 			// 由于是同一个 issue，可以一次性完成更新，移除
 			updatedIssue, err := tools.Issue.EditByIssueRequest(existIssue.GetNumber(), tools.Generate.UpdateIssueRequest(true, f.CommitFile.GetPreviousFilename(), tools.Generate.UpdateIssue(false, *f.CommitFile.Filename, *existIssue)))
 			if err != nil {
-				return
+				return nil, err
 			}
+			tools.Issue.Index(updatedIssue, f.CommitFile.GetPreviousFilename())
 			// comment
-			_ = f.comment(updatedIssue)
-		} else {
-			// 由于 existIssue 和 preIssue 不是同一个 issue
-			// 需要分别完成更新、移除
-			f.update(existIssue)
-			f.remove(preIssue)
+			f.notify(updatedIssue)
+			return issues(updatedIssue, nil)
 		}
-	} else {
-		// existIssue == nil，
-		// 此时，创建 issue，并在 preIssue 中移除旧文件名
-		f.create(include)
-		// 尝试移除
-		if preIssue != nil {
-			f.remove(preIssue)
+		// 由于 existIssue 和 preIssue 不是同一个 issue
+		// 需要分别完成更新、移除
+		updatedIssue, err := f.update(existIssue)
+		if err != nil {
+			return nil, err
 		}
+		removedIssue, err := f.remove(preIssue)
+		if err != nil || removedIssue == nil {
+			return issues(updatedIssue, err)
+		}
+		return []*github.Issue{updatedIssue, removedIssue}, nil
+	}
+	// existIssue == nil，
+	// 此时，创建 issue，并在 preIssue 中移除旧文件名
+	newIssue, err := f.create(include)
+	if err != nil {
+		return nil, err
+	}
+	// 尝试移除
+	removedIssue, err := f.remove(preIssue)
+	if err != nil || removedIssue == nil {
+		return issues(newIssue, err)
 	}
+	return []*github.Issue{newIssue, removedIssue}, nil
 }
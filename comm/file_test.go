@@ -0,0 +1,55 @@
+package comm
+
+import (
+	"github.com/google/go-github/v30/github"
+	"issue-man/config"
+	"issue-man/global"
+	"reflect"
+	"testing"
+)
+
+func TestFile_Plan(t *testing.T) {
+	global.Conf = &config.Config{}
+	global.Conf.Repository.Spec.Workspace.Detection.Ignore.Messages = []string{"(?i)typo"}
+	include := config.Include{Title: "docs/intro"}
+	issue := func(number int) *github.Issue {
+		return &github.Issue{Number: &number}
+	}
+	file := func(status, filename, previous string) File {
+		cf := &github.CommitFile{Status: &status, Filename: &filename}
+		if previous != "" {
+			cf.PreviousFilename = &previous
+		}
+		return File{CommitFile: cf}
+	}
+	typo := func(f File) File {
+		f.Message = "Fix typo"
+		return f
+	}
+
+	tests := []struct {
+		name       string
+		file       File
+		existIssue *github.Issue
+		preIssue   *github.Issue
+		want       []string
+	}{
+		{name: "create", file: file(ADD, "a.md", ""), want: []string{`create issue "docs/intro" with a.md`}},
+		{name: "update", file: file(MODIFY, "a.md", ""), existIssue: issue(1), want: []string{"update #1: modified a.md"}},
+		{name: "rename in issue", file: file(RENAME, "b.md", "a.md"), existIssue: issue(1), preIssue: issue(1), want: []string{"update #1: rename a.md to b.md"}},
+		{name: "rename across issues", file: file(RENAME, "b.md", "a.md"), existIssue: issue(2), preIssue: issue(1), want: []string{"update #2: add b.md", "update #1: remove a.md"}},
+		{name: "rename to new issue", file: file(RENAME, "b.md", "a.md"), preIssue: issue(1), want: []string{`create issue "docs/intro" with b.md`, "update #1: remove a.md"}},
+		{name: "remove", file: file(REMOVE, "a.md", ""), existIssue: issue(1), want: []string{"update #1: remove a.md"}},
+		{name: "remove without issue", file: file(REMOVE, "a.md", ""), want: nil},
+		{name: "reopen", file: file(MODIFY, "a.md", ""), existIssue: &github.Issue{Number: github.Int(1), State: github.String(closed)}, want: []string{"update #1 (reopen): modified a.md"}},
+		{name: "ignored keeps closed", file: typo(file(MODIFY, "a.md", "")), existIssue: &github.Issue{Number: github.Int(1), State: github.String(closed)}, want: []string{"update #1: modified a.md (ignored: message, recorded in body, no reopen, label or comment)"}},
+		{name: "to be created", file: file(MODIFY, "a.md", ""), existIssue: &github.Issue{Title: github.String("docs/intro")}, want: []string{`update issue "docs/intro" (to be created): modified a.md`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.file.Plan(include, tt.existIssue, tt.preIssue); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Plan() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
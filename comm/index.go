@@ -0,0 +1,35 @@
+package comm
+
+import (
+	"github.com/google/go-github/v30/github"
+	"issue-man/global"
+	"issue-man/store"
+)
+
+// IndexedTitle 根据文件与 issue 的索引找到文件所属 issue 的 title
+// title 为根据当前配置生成的 title，配置变动（如 include、文件类型）导致 title 变化时，
+// 仍然可以通过索引找到原 issue，避免创建重复的 issue
+// 索引不存在，或索引指向的 issue 不在 existIssues 中时，返回 title
+func IndexedTitle(existIssues map[string]*github.Issue, file, title string) string {
+	if global.Store == nil || file == "" {
+		return title
+	}
+	number, ok, err := store.GetIssueIndex(global.Store, file)
+	if err != nil {
+		global.Sugar.Errorw("get issue index",
+			"status", "fail",
+			"file", file,
+			"err", err.Error())
+		return title
+	}
+	if !ok || existIssues[title].GetNumber() == number {
+		return title
+	}
+	for k, v := range existIssues {
+		// 关联的 update issue 由 Resolve 处理
+		if v.GetNumber() == number && k != UpdateTitle(title) {
+			return k
+		}
+	}
+	return title
+}
@@ -0,0 +1,53 @@
+package comm
+
+import (
+	"github.com/google/go-github/v30/github"
+	"go.uber.org/zap"
+	"issue-man/config"
+	"issue-man/global"
+	"issue-man/store"
+	"testing"
+)
+
+func TestIndexedTitle(t *testing.T) {
+	global.Conf = &config.Config{}
+	global.Sugar = zap.NewNop().Sugar()
+	global.Store = store.NewMemory()
+	defer func() { global.Store = nil }()
+
+	issue := func(number int, title string) *github.Issue {
+		return &github.Issue{Number: &number, Title: &title}
+	}
+	existIssues := map[string]*github.Issue{
+		"docs/a":              issue(1, "docs/a"),
+		"old/b":               issue(2, "old/b"),
+		"docs/c":              issue(3, "docs/c"),
+		UpdateTitle("docs/d"): issue(4, UpdateTitle("docs/d")),
+	}
+	for file, number := range map[string]int{"a.md": 1, "b.md": 2, "c.md": 1, "d.md": 4, "e.md": 5} {
+		if err := store.PutIssueIndex(global.Store, file, number); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		file  string
+		title string
+		want  string
+	}{
+		{name: "same title", file: "a.md", title: "docs/a", want: "docs/a"},
+		{name: "renamed title", file: "b.md", title: "docs/b", want: "old/b"},
+		{name: "moved to another issue", file: "c.md", title: "docs/c", want: "docs/a"},
+		{name: "update issue", file: "d.md", title: "docs/d", want: "docs/d"},
+		{name: "issue not exist", file: "e.md", title: "docs/e", want: "docs/e"},
+		{name: "no index", file: "f.md", title: "docs/f", want: "docs/f"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IndexedTitle(existIssues, tt.file, tt.title); got != tt.want {
+				t.Errorf("IndexedTitle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
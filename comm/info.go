@@ -1,6 +1,7 @@
 package comm
 
 import (
+	gg "github.com/google/go-github/v30/github"
 	"github.com/google/uuid"
 	"gopkg.in/go-playground/webhooks.v5/github"
 	"issue-man/global"
@@ -15,9 +16,15 @@ type Info struct {
 
 	// 评论人信息
 	Login string
+	// 评论的 ID，定时任务等场景下为 0
+	CommentID int64
 	// 评论提及到的人
 	Mention []string
 
+	// 指令名及其参数（指令名之后的全部内容）
+	Instruct string
+	Args     []string
+
 	// Issue 目前的信息
 	IssueURL    string
 	IssueNumber int
@@ -49,6 +56,7 @@ func (p *Info) Parse(payload github.IssueCommentPayload) {
 	p.Repository = payload.Repository.Name
 
 	p.Login = payload.Sender.Login
+	p.CommentID = payload.Comment.ID
 
 	p.IssueURL = payload.Issue.URL
 	p.IssueNumber = int(payload.Issue.Number)
@@ -71,3 +79,35 @@ func (p *Info) Parse(payload github.IssueCommentPayload) {
 		}
 	}
 }
+
+// ParseIssue
+// 从 GitHub API 返回的 issue 中解析信息
+// 用于定时任务等没有 webhook payload 的场景，此时 Login 为 issue 的第一个 assignee
+func (p *Info) ParseIssue(issue *gg.Issue) {
+	p.ReqID = uuid.New().String()
+	p.Owner = global.Conf.Repository.Spec.Workspace.Owner
+	p.Repository = global.Conf.Repository.Spec.Workspace.Repository
+
+	p.IssueURL = issue.GetURL()
+	p.IssueNumber = issue.GetNumber()
+	p.Title = issue.GetTitle()
+	p.Body = issue.GetBody()
+	p.State = issue.GetState()
+
+	if issue.Milestone != nil {
+		p.Milestone = issue.Milestone.GetNumber()
+	}
+
+	p.Assignees = make([]string, len(issue.Assignees))
+	for k, v := range issue.Assignees {
+		p.Assignees[k] = v.GetLogin()
+	}
+	p.Labels = make([]string, len(issue.Labels))
+	for k, v := range issue.Labels {
+		p.Labels[k] = v.GetName()
+	}
+
+	if len(p.Assignees) > 0 {
+		p.Login = p.Assignees[0]
+	}
+}
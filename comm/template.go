@@ -0,0 +1,125 @@
+package comm
+
+import (
+	"bytes"
+	"issue-man/config"
+	"issue-man/global"
+	"issue-man/tools"
+	"sync"
+	"text/template"
+)
+
+var (
+	// 从 Repository.Spec.Templates 加载的模板，配置变化（即 global.Conf 被替换）后重新加载
+	// feedback 中可以通过 {{template "<文件名>" .}} 引用
+	baseTemplate *template.Template
+	templateConf *config.Config
+	templateLock sync.Mutex
+)
+
+// feedback 模板中可以使用的数据
+type templateData struct {
+	Commenter string
+	Count     int
+	ResetDate string
+	ReqID     string
+	Assignees []string
+	Mention   []string
+
+	// 指令及其参数
+	Instruct string
+	Args     []string
+
+	// 未通过的检查，及其要求
+	Rule    string
+	Require []string
+
+	Issue  templateIssue
+	Config *config.Config
+}
+
+type templateIssue struct {
+	Number    int
+	Title     string
+	URL       string
+	State     string
+	Labels    []string
+	Assignees []string
+	Files     []string
+}
+
+// 加载配置的模板文件，同一份配置只加载一次
+func loadTemplates() *template.Template {
+	templateLock.Lock()
+	defer templateLock.Unlock()
+	if baseTemplate != nil && templateConf == global.Conf {
+		return baseTemplate
+	}
+	t, errs := global.Conf.Repository.Templates(global.Location)
+	for _, err := range errs {
+		global.Sugar.Errorw("load templates",
+			"err", err.Error())
+	}
+	baseTemplate, templateConf = t, global.Conf
+	return baseTemplate
+}
+
+// 生成模板数据
+func (r Comment) templateData() templateData {
+	data := templateData{
+		Commenter: r.Login,
+		Count:     r.LimitCount,
+		ResetDate: r.ResetDate,
+		ReqID:     r.ReqID,
+		Assignees: r.Assignees,
+		Rule:      r.Rule,
+		Require:   r.Require,
+		Config:    global.Conf,
+	}
+	if r.Info != nil {
+		data.Mention = r.Info.Mention
+		data.Instruct = r.Info.Instruct
+		data.Args = r.Info.Args
+		data.Issue = templateIssue{
+			Number:    r.Info.IssueNumber,
+			Title:     r.Info.Title,
+			URL:       r.Info.IssueURL,
+			State:     r.Info.State,
+			Labels:    r.Info.Labels,
+			Assignees: r.Info.Assignees,
+			Files:     tools.Parse.FilesFromBody(r.Info.Body),
+		}
+	}
+	return data
+}
+
+// 执行模板，出错时返回原文本
+func (r Comment) execute(text string) string {
+	base, err := loadTemplates().Clone()
+	if err != nil {
+		global.Sugar.Errorw("execute feedback template",
+			"req_id", r.ReqID,
+			"step", "clone",
+			"err", err.Error())
+		return text
+	}
+	t, err := base.New("feedback").Parse(text)
+	if err != nil {
+		global.Sugar.Errorw("execute feedback template",
+			"req_id", r.ReqID,
+			"step", "parse",
+			"text", text,
+			"err", err.Error())
+		return text
+	}
+	bf := bytes.Buffer{}
+	if err := t.Execute(&bf, r.templateData()); err != nil {
+		global.Sugar.Errorw("execute feedback template",
+			"req_id", r.ReqID,
+			"step", "execute",
+			"text", text,
+			"err", err.Error())
+		return text
+	}
+	return bf.String()
+}
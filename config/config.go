@@ -2,7 +2,6 @@ package config
 
 import (
 	"fmt"
-	"path"
 	"strings"
 )
 
@@ -11,6 +10,7 @@ type Config struct {
 	IssueCreate   IssueCreate    `yaml:"issue_create"`
 	IssueComments []IssueComment `yaml:"issue_comment"`
 	Jobs          []Job          `yaml:"jobs"`
+	Workflows     []Workflow     `yaml:"workflows"`
 }
 
 type Base struct {
@@ -35,7 +35,7 @@ func (s Selector) GetFullName() string {
 
 // 仓库及一些全局相关的配置
 type Repository struct {
-	Base
+	Base `yaml:",inline"`
 	Spec struct {
 		Source    Selector `yaml:"source"`    // 源库
 		Translate Selector `yaml:"translate"` // 翻译库
@@ -44,9 +44,31 @@ type Repository struct {
 			Repository     string `yaml:"repository"`
 			MaintainerTeam string `yaml:"maintainerTeam"`
 			Detection      struct {
-				Enable  bool   `yaml:"enable"` // 是否检测同步更新 issue
-				At      string `yaml:"at"`
-				PRIssue int    `yaml:"prIssue"`
+				Enable bool `yaml:"enable"` // 是否检测同步更新 issue
+				// 已废弃，请使用 schedule，"HH:MM" 等价于 "MM HH * * *"
+				At string `yaml:"at"`
+				// 检测的时间，cron 格式，如 "0 2 * * *"、"@daily"
+				// 同时也是 Job 默认的执行时间
+				Schedule string `yaml:"schedule"`
+				PRIssue  int    `yaml:"prIssue"`
+				// 检测上游变动的方式，默认为 pr
+				// pr：遍历上次处理的 pr 之后 merged 的 pr
+				// commit：比较上次处理的 commit 与分支最新的 commit，可以检测到直接 push、cherry-pick 等不经过 pr 的改动
+				Mode string `yaml:"mode"`
+				// 除 source.branch 外，额外检测的分支，如 release-1.0
+				// 每个分支单独记录检测进度，改动同样会同步至对应的 issue
+				Branches []string `yaml:"branches"`
+				// 检测进度的保存位置，默认为 store
+				// store：保存在运行时状态的存储中，同时更新 prIssue 的 body 以便查看
+				// issue：以 prIssue 的 body 为准，与旧版本的行为一致
+				Checkpoint string `yaml:"checkpoint"`
+				// 上游文件有变动，但对应的 issue 已关闭时的处理方式，默认为 reopen
+				// reopen：重新打开该 issue，并 comment
+				// update：创建一个关联的 update issue，附带翻译之后上游的改动
+				// ignore：不做处理
+				Closed string `yaml:"closed"`
+				// 不重要的改动（如空白字符、错别字、front matter），不添加 addLabel，也不 comment
+				Ignore Significance `yaml:"ignore"`
 				// Comment Need Label
 				NeedLabel       []string `yaml:"needLabel"`
 				AddLabel        []string `yaml:"addLabel"`
@@ -57,27 +79,66 @@ type Repository struct {
 				Name        string `yaml:"name"`
 				Description string `yaml:"description"`
 			} `yaml:"labels"` // 初始化时，自动创建的 label
+			// 包含指令的评论被编辑、删除时的处理方式
+			Comment struct {
+				// edited：new 表示仅执行新增的指令，ignore 表示忽略，默认为 ignore
+				Edited string `yaml:"edited"`
+				// deleted：undo 表示撤销该评论中的指令做出的改动，ignore 表示忽略，默认为 ignore
+				Deleted string `yaml:"deleted"`
+			} `yaml:"comment"`
 		} `yaml:"workspace"` // 工作库
 		Port     string `yaml:"port"`
 		LogLevel string `yaml:"logLevel"`
 		Verbose  bool   `yaml:"verbose"`
+		// 互斥的 label 组，添加组内的 label 时，会移除同组的其它 label
+		LabelGroups []LabelGroup `yaml:"labelGroups"`
+		// 定时任务使用的时区，IANA 格式，如 "Asia/Shanghai"，默认为系统时区
+		Timezone string `yaml:"timezone"`
+		// 定时任务的随机延迟上限，如 "5m"，避免多个实例同时调用 API
+		Jitter string `yaml:"jitter"`
+		// 项目默认语言，如 zh、en，用于选择多语言的 feedback 及内置文本
+		Language string `yaml:"language"`
+		// 表示 issue 语言的 label 前缀，默认为 lang/，例如 lang/ja
+		LanguageLabelPrefix string `yaml:"languageLabelPrefix"`
+		// 用户偏好的语言，key 为 GitHub 用户名
+		UserLanguages map[string]string `yaml:"userLanguages"`
+		// 覆盖内置文本（如 issue body、同步提示），key 见 DefaultMessages
+		Messages map[string]Text `yaml:"messages"`
+		// feedback 模板文件，支持通配符，如 "./templates/*.tmpl"
+		// 在 feedback 中可以通过 {{template "<文件名>" .}} 引用
+		Templates []string `yaml:"templates"`
+		// 运行时状态（如截止时间）的存储位置
+		Store struct {
+			// 存储类型，bolt、file 或 issue，默认为 bolt
+			// 未配置时，.json 结尾的 path 视为 file，以兼容旧版本的配置
+			// issue 将数据保存在工作仓库 issue 的 body 中，用于没有可写磁盘的部署
+			Backend string `yaml:"backend"`
+			// 默认为 ./issue-man.db，file 类型默认为 ./issue-man.json
+			Path string `yaml:"path"`
+			// issue 类型保存数据的 issue number，默认为 detection.prIssue
+			Issue int `yaml:"issue"`
+		} `yaml:"store"`
 	} `yaml:"spec"`
 }
 
 // 创建 Issue 相关的配置
 type IssueCreate struct {
-	Base
+	Base `yaml:",inline"`
 	Spec struct {
 		Prefix string `yaml:"prefix"`
 		// 默认为 false，即默认会在 title 里移除 prefix 的部分
 		SaveTitlePrefix bool     `yaml:"saveTitlePrefix"`
-		FileType        []string `yaml:"fileType"`
+		FileType        []string `yaml:"fileType"` // 支持的扩展名，可以是复合扩展名，如 md、rst.txt
 		Labels          []string `yaml:"labels"`
 		Assignees       []string `yaml:"assignees"`
 		Milestone       int      `yaml:"milestone"`
+		// 按文件类型配置 URL 后缀、label 和分类依据，其中的扩展名同样视为支持的扩展名
+		Types []FileType `yaml:"types"`
 		// 分类依据，可选值为 directory、file，默认为 directory
 		GroupBy  string    `yaml:"groupBy"`
 		Includes []Include `yaml:"includes"`
+		// 多个 include 匹配时的选择方式，可选值为 first、specific，默认为 first
+		Match string `yaml:"match"`
 	} `yaml:"spec"`
 }
 
@@ -88,49 +149,23 @@ func (i IssueCreate) SupportType(file string) bool {
 		return false
 	}
 
-	ext := strings.ReplaceAll(path.Ext(file), ".", "")
-	for _, v := range i.Spec.FileType {
-		// 后缀匹配
-		if v == ext {
-			return true
-		}
-	}
-	return false
+	// 后缀匹配，支持复合扩展名
+	_, ok := i.TypeOf(file)
+	return ok
 }
 
 // 判断是否处理该文件
-// 如果处理，则返回其匹配的相关信息
+// 如果处理，则返回其匹配的相关信息，匹配规则见 Explain
 func (i IssueCreate) SupportFile(filename string) (Include, bool) {
-	// 仅处理支持的文件格式
-	if !i.SupportType(filename) {
-		return Include{}, false
-	}
-
-	for _, include := range i.Spec.Includes {
-		// 包含关键字
-		if strings.Contains(filename, include.Path) {
-			// 如果不包含任一排除的子目录，则符合条件
-			if !i.hasExclude(filename, include.Exclude) {
-				return include, true
-			}
-		}
-	}
-
-	// 无匹配 include
-	return Include{}, false
-}
-
-func (i IssueCreate) hasExclude(filename string, excludes []Include) bool {
-	for _, v := range excludes {
-		if strings.Contains(filename, v.Path) {
-			return true
-		}
-	}
-	return false
+	m, ok := i.Explain(filename)
+	return m.Include, ok
 }
 
 type Include struct {
 	Path string `yaml:"path"`
+	// path 的匹配方式，可选值为 path、glob、regex
+	// 默认为 path，path 中包含 *、?、[、{ 时为 glob
+	Match string `yaml:"match"`
 
 	// 对于这一类文件，将 title 强制重写为配置文件指定的内容
 	// 并且不显示 website 地址和 commit 历史界面
@@ -140,13 +175,16 @@ type Include struct {
 	GroupBy string    `yaml:"groupBy"`
 	Labels  []string  `yaml:"labels"`
 	Exclude []Include `yaml:"exclude"`
+	// 嵌套的 include，在上级 include 匹配后继续匹配
+	// 未配置的 title、groupBy 继承上级 include，labels 与上级 include 合并
+	Includes []Include `yaml:"includes"`
 }
 
 // Issue Comment 相关的配置
 // 也就是指令相关的配置
 // 创建 Issue 相关的配置
 type IssueComment struct {
-	Base
+	Base `yaml:",inline"`
 	Spec struct {
 		Rules  *Rule   `yaml:"rules"`
 		Action *Action `yaml:"action"`
@@ -157,35 +195,66 @@ type IssueComment struct {
 type Rule struct {
 	Instruct           string   `yaml:"instruct"`
 	Permissions        []string `yaml:"permissions"`
-	PermissionFeedback string   `yaml:"permissionFeedback"`
+	PermissionFeedback Text     `yaml:"permissionFeedback"`
 	Labels             []string `yaml:"labels"`
-	LabelFeedback      string   `yaml:"labelFeedback"`
+	LabelFeedback      Text     `yaml:"labelFeedback"`
 	Assignees          []string `yaml:"assignees"`
-	AssignerFeedback   string   `yaml:"assignerFeedback"`
+	AssignerFeedback   Text     `yaml:"assignerFeedback"`
 }
 
 // 动作
 type Action struct {
 	AddLabels          []string `yaml:"addLabels"`
 	AddLabelsLimit     int      `yaml:"addLabelsLimit"`
-	LabelLimitFeedback string   `yaml:"labelLimitFeedback"`
+	LabelLimitFeedback Text     `yaml:"labelLimitFeedback"`
 	RemoveLabels       []string `yaml:"removeLabels"`
 	AddAssignees       []string `yaml:"addAssignees"`
 	RemoveAssignees    []string `yaml:"removeAssignees"`
 	State              string   `yaml:"state"`
-	SuccessFeedback    string   `yaml:"successFeedback"`
-	FailFeedback       string   `yaml:"failFeedback"`
+	SuccessFeedback    Text     `yaml:"successFeedback"`
+	FailFeedback       Text     `yaml:"failFeedback"`
+	// 延长某个 Job 的截止时间，例如 /delay-reset
+	Delay *Delay `yaml:"delay"`
+	// 撤销指令对 issue 做出的改动，例如 /undo [req-id]
+	// 不指定 req-id 时，撤销最近一次未被撤销的改动
+	Undo bool `yaml:"undo"`
+}
+
+// 延期
+type Delay struct {
+	Job  string `yaml:"job"`  // 延长哪个 Job 的截止时间
+	Days int    `yaml:"days"` // 每次延长的天数
+	// 最多可以延期的次数，小于等于 0 表示不限制
+	Limit         int  `yaml:"limit"`
+	LimitFeedback Text `yaml:"limitFeedback"`
 }
 
 // Job 定时任务相关的配置
 // 也就是定时更新和状态检测相关的配置
 // 同时，依赖 `创建 Issue` 的配置
 type Job struct {
-	Base
+	Base `yaml:",inline"`
 	Spec struct {
-		In           int      `yaml:"in"`
-		Labels       []string `yaml:"labels"`
-		AddLabels    []string `yaml:"addLabels"`
-		RemoveLabels []string `yaml:"removeLabels"`
+		// issue 处于 Labels 状态的天数，超过该天数才会执行任务
+		In     int      `yaml:"in"`
+		Labels []string `yaml:"labels"`
+		// 满足条件时，对 issue 做出的改动
+		// assignees 支持的值与指令相同，其中 @commenter 表示 issue 的第一个 assignee
+		AddLabels       []string `yaml:"addLabels"`
+		RemoveLabels    []string `yaml:"removeLabels"`
+		AddAssignees    []string `yaml:"addAssignees"`
+		RemoveAssignees []string `yaml:"removeAssignees"`
+		Feedback        Text     `yaml:"feedback"`
+		// 到期前的提醒，每天最多提醒一次
+		Remind struct {
+			// 距离截止时间的天数，剩余时间进入某个阶段时提醒一次
+			// 默认为 8、4、2、1，即越临近截止时间，提醒越频繁
+			Days     []int `yaml:"days"`
+			Feedback Text  `yaml:"feedback"`
+		} `yaml:"remind"`
+		// 执行时间，cron 格式，默认与 detection.schedule 相同
+		Schedule string `yaml:"schedule"`
+		// 为 true 时，只打印将要处理的 issue，不做任何改动
+		DryRun bool `yaml:"dryRun"`
 	} `yaml:"spec"`
 }
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"bytes"
+	"gopkg.in/yaml.v2"
+)
+
+// MarshalDocuments 将多段配置输出为以 --- 分隔的 yaml，省略空的字段
+func MarshalDocuments(values ...interface{}) ([]byte, error) {
+	bf := bytes.Buffer{}
+	for k, v := range values {
+		// 先转换为有序的 map，以便省略空的字段，并保持字段的顺序
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		doc := yaml.MapSlice{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		data, err = yaml.Marshal(prune(doc))
+		if err != nil {
+			return nil, err
+		}
+		if k > 0 {
+			bf.WriteString("---\n")
+		}
+		bf.Write(data)
+	}
+	return bf.Bytes(), nil
+}
+
+// 递归地移除空的字段，返回 nil 表示该值为空
+func prune(v interface{}) interface{} {
+	switch value := v.(type) {
+	case yaml.MapSlice:
+		result := yaml.MapSlice{}
+		for _, item := range value {
+			if p := prune(item.Value); p != nil {
+				result = append(result, yaml.MapItem{Key: item.Key, Value: p})
+			}
+		}
+		if len(result) == 0 {
+			return nil
+		}
+		return result
+	case []interface{}:
+		if len(value) == 0 {
+			return nil
+		}
+		result := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			result = append(result, prune(item))
+		}
+		return result
+	case string:
+		if value == "" {
+			return nil
+		}
+	case int:
+		if value == 0 {
+			return nil
+		}
+	case bool:
+		if !value {
+			return nil
+		}
+	case nil:
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarshalDocuments(t *testing.T) {
+	legacy := Legacy{
+		FullRepositoryName: "gorda/gorda.io",
+		Flows: []LegacyFlow{
+			{Name: "/merged", Permission: []string{"self"}, CurrentLabel: []string{"status/reviewing"}, Close: true},
+		},
+	}
+	repository, comments, warnings := legacy.Convert()
+	if len(warnings) != 0 {
+		t.Errorf("Convert() warnings = %v", warnings)
+	}
+	data, err := MarshalDocuments(repository, comments[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 转换后的配置可以被重新解析
+	conf, problems := Parse("converted.yaml", data)
+	if HasError(problems) {
+		t.Fatalf("Parse() problems = %v\n%s", problems, data)
+	}
+	if !reflect.DeepEqual(conf.Repository.Spec.Workspace, repository.Spec.Workspace) {
+		t.Errorf("Parse() workspace = %+v, want %+v", conf.Repository.Spec.Workspace, repository.Spec.Workspace)
+	}
+	if !reflect.DeepEqual(conf.IssueComments[0].Spec, comments[0].Spec) {
+		t.Errorf("Parse() instruct = %+v, want %+v", conf.IssueComments[0].Spec, comments[0].Spec)
+	}
+}
+
+func TestParseLegacy(t *testing.T) {
+	data := `full_repository_name: "gorda/gorda.io"
+unknown_top: 1
+flows:
+  - name: "/merged"
+    bogus_key: "x"
+    close: true
+`
+	legacy, dropped, err := ParseLegacy([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"line 2: field unknown_top not found in type config.Legacy, dropped",
+		"line 5: field bogus_key not found in type config.LegacyFlow, dropped",
+	}
+	if !reflect.DeepEqual(dropped, want) {
+		t.Errorf("ParseLegacy() dropped = %v, want %v", dropped, want)
+	}
+	if legacy.FullRepositoryName != "gorda/gorda.io" || len(legacy.Flows) != 1 || !legacy.Flows[0].Close {
+		t.Errorf("ParseLegacy() legacy = %+v", legacy)
+	}
+}
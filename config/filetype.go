@@ -0,0 +1,73 @@
+package config
+
+import (
+	"path"
+	"strings"
+)
+
+// FileType 一种文件类型的处理方式
+type FileType struct {
+	// 扩展名，不包括开头的 .，可以是复合扩展名，如 rst.txt、md.tmpl
+	Ext string `yaml:"ext"`
+	// 生成网页 URL 时，从文件名中去除的后缀，默认为 .<ext>，如 .rst.txt
+	// 为兼容旧版本，txt 类型的 .rst.txt 文件默认去除 .rst.txt
+	TrimSuffix string `yaml:"trimSuffix"`
+	// 创建 issue 时额外添加的 label，如 type/rst
+	Labels []string `yaml:"labels"`
+	// 分类依据，可选值为 directory、file，优先级低于 include 的 groupBy，高于 spec.groupBy
+	GroupBy string `yaml:"groupBy"`
+}
+
+// FileTypes 返回支持的文件类型，包括 spec.fileType 和 spec.types
+// 同一个扩展名以 spec.types 中的配置为准
+func (i IssueCreate) FileTypes() []FileType {
+	types := make([]FileType, 0, len(i.Spec.FileType)+len(i.Spec.Types))
+	configured := make(map[string]bool)
+	for _, v := range i.Spec.Types {
+		v.Ext = strings.TrimPrefix(v.Ext, ".")
+		configured[v.Ext] = true
+		types = append(types, v)
+	}
+	for _, v := range i.Spec.FileType {
+		v = strings.TrimPrefix(v, ".")
+		if !configured[v] {
+			configured[v] = true
+			types = append(types, FileType{Ext: v})
+		}
+	}
+	return types
+}
+
+// TypeOf 返回文件的类型，多个扩展名匹配时，选择最长的一个，如 a.rst.txt 优先匹配 rst.txt，其次是 txt
+func (i IssueCreate) TypeOf(file string) (FileType, bool) {
+	base := path.Base(file)
+	var matched FileType
+	ok := false
+	for _, v := range i.FileTypes() {
+		if strings.HasSuffix(base, "."+v.Ext) && len(v.Ext) > len(matched.Ext) {
+			matched, ok = v, true
+		}
+	}
+	if ok && matched.TrimSuffix == "" {
+		matched.TrimSuffix = "." + matched.Ext
+		// 旧版本按文件分类时固定去除 .rst.txt，配置为 txt 时保持原来的 URL
+		if matched.Ext == "txt" && strings.HasSuffix(base, ".rst.txt") {
+			matched.TrimSuffix = ".rst.txt"
+		}
+	}
+	return matched, ok
+}
+
+// GroupByOf 返回文件的分类依据，文件类型未配置时为 spec.groupBy
+func (i IssueCreate) GroupByOf(file string) string {
+	if t, ok := i.TypeOf(file); ok && t.GroupBy != "" {
+		return t.GroupBy
+	}
+	return i.Spec.GroupBy
+}
+
+// PageName 返回文件在网页 URL 中的名称，即去除后缀后的文件名，如 intro.rst.txt 返回 intro
+func (i IssueCreate) PageName(file string) string {
+	t, _ := i.TypeOf(file)
+	return strings.TrimSuffix(path.Base(file), t.TrimSuffix)
+}
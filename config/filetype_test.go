@@ -0,0 +1,50 @@
+package config
+
+import "testing"
+
+func TestIssueCreate_TypeOf(t *testing.T) {
+	i := IssueCreate{}
+	i.Spec.FileType = []string{"txt", "md", "rst.txt"}
+	i.Spec.Types = []FileType{
+		{Ext: "md.tmpl", Labels: []string{"type/template"}, GroupBy: "file"},
+		{Ext: ".html", TrimSuffix: ".htm"},
+	}
+	i.Spec.GroupBy = "directory"
+
+	tests := []struct {
+		name     string
+		file     string
+		types    []string
+		wantExt  string
+		wantOK   bool
+		wantPage string
+		wantBy   string
+	}{
+		{name: "simple", file: "docs/a.md", wantExt: "md", wantOK: true, wantPage: "a", wantBy: "directory"},
+		{name: "compound", file: "docs/intro.rst.txt", wantExt: "rst.txt", wantOK: true, wantPage: "intro", wantBy: "directory"},
+		{name: "shorter ext", file: "docs/notes.txt", wantExt: "txt", wantOK: true, wantPage: "notes", wantBy: "directory"},
+		{name: "legacy txt", file: "docs/intro.rst.txt", types: []string{"txt"}, wantExt: "txt", wantOK: true, wantPage: "intro", wantBy: "directory"},
+		{name: "configured type", file: "docs/a.md.tmpl", wantExt: "md.tmpl", wantOK: true, wantPage: "a", wantBy: "file"},
+		{name: "trim suffix", file: "docs/a.html", wantExt: "html", wantOK: true, wantPage: "a.html", wantBy: "directory"},
+		{name: "unsupported", file: "docs/a.tmpl", wantOK: false, wantPage: "a.tmpl", wantBy: "directory"},
+		{name: "ext only in directory", file: "docs.md/a", wantOK: false, wantPage: "a", wantBy: "directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := i
+			if tt.types != nil {
+				i.Spec.FileType = tt.types
+			}
+			got, ok := i.TypeOf(tt.file)
+			if got.Ext != tt.wantExt || ok != tt.wantOK {
+				t.Errorf("TypeOf() = %q, %v, want %q, %v", got.Ext, ok, tt.wantExt, tt.wantOK)
+			}
+			if page := i.PageName(tt.file); page != tt.wantPage {
+				t.Errorf("PageName() = %q, want %q", page, tt.wantPage)
+			}
+			if by := i.GroupByOf(tt.file); by != tt.wantBy {
+				t.Errorf("GroupByOf() = %q, want %q", by, tt.wantBy)
+			}
+		})
+	}
+}
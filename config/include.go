@@ -0,0 +1,309 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// include 中 path 的匹配方式
+const (
+	// 按目录或文件名匹配，path 需要是文件路径中完整的一段或几段，如 docs/ops 不匹配 docs/ops-legacy
+	MatchPath = "path"
+	// doublestar 通配符，** 匹配任意层目录，支持 {a,b}
+	MatchGlob = "glob"
+	// 正则，需要匹配完整的路径
+	MatchRegex = "regex"
+)
+
+// 多个 include 匹配时的选择方式
+const (
+	// 按配置顺序，选择第一个匹配的 include
+	MatchFirst = "first"
+	// 选择最具体的 include，即嵌套最深、path 中除通配符外字符最多的 include
+	MatchSpecific = "specific"
+)
+
+// IncludeMatch 文件与 include 的匹配结果
+type IncludeMatch struct {
+	// 匹配的 include，未配置的 title、groupBy 继承上级 include，labels 与上级 include 合并
+	Include Include
+	// 从顶层到匹配的 include
+	Chain []Include
+	// 匹配过程，用于解释为什么匹配或不匹配
+	Trace []string
+}
+
+// MatchMode 返回 path 的匹配方式，未配置时，path 中包含通配符的为 MatchGlob，其余为 MatchPath
+func (i Include) MatchMode() string {
+	if i.Match != "" {
+		return i.Match
+	}
+	if strings.ContainsAny(i.Path, "*?[{") {
+		return MatchGlob
+	}
+	return MatchPath
+}
+
+// String 返回匹配方式和 path，如 glob "docs/**/*.md"
+func (i Include) String() string {
+	return fmt.Sprintf("%s %q", i.MatchMode(), i.Path)
+}
+
+// 如 path "docs" > glob "ops/**"
+func chainString(chain []Include) string {
+	names := make([]string, 0, len(chain))
+	for _, v := range chain {
+		names = append(names, v.String())
+	}
+	return strings.Join(names, " > ")
+}
+
+// Matches 判断文件是否匹配 path，不考虑 exclude
+// filename 为完整的文件路径，relative 为去除 IssueCreate.Spec.Prefix 后的路径
+// glob 和 regex 与 relative 匹配，path 与 filename 匹配
+func (i Include) Matches(filename, relative string) (bool, error) {
+	switch i.MatchMode() {
+	case MatchGlob:
+		return globMatch(i.Path, relative)
+	case MatchRegex:
+		re, err := regexp.Compile("^(?:" + i.Path + ")$")
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(relative), nil
+	case MatchPath:
+		return pathMatch(i.Path, filename), nil
+	default:
+		return false, fmt.Errorf("unknown match %q, want %s, %s or %s", i.Match, MatchPath, MatchGlob, MatchRegex)
+	}
+}
+
+// Validate 检查 include 及其 exclude、嵌套 include 的 path
+func (i Include) Validate() error {
+	if _, err := i.Matches("", ""); err != nil {
+		return fmt.Errorf("bad include path %q: %s", i.Path, err.Error())
+	}
+	for _, v := range i.Exclude {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, v := range i.Includes {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 用于比较 include 的具体程度，优先比较不含通配符的目录层数，其次是除通配符外的字符数
+func (i Include) specificity() int {
+	meta := `*?[]{},+()|^$\`
+	if i.MatchMode() == MatchPath {
+		meta = ""
+	}
+	segments, chars := 0, 0
+	for _, segment := range strings.Split(strings.Trim(i.Path, "/"), "/") {
+		if segment != "" && !strings.ContainsAny(segment, meta) {
+			segments++
+		}
+		for _, c := range segment {
+			if !strings.ContainsRune(meta, c) {
+				chars++
+			}
+		}
+	}
+	return segments*1000 + chars
+}
+
+// Explain 返回文件匹配的 include，以及匹配的过程
+func (i IssueCreate) Explain(filename string) (IncludeMatch, bool) {
+	m := IncludeMatch{Trace: make([]string, 0)}
+	if !i.SupportType(filename) {
+		exts := make([]string, 0)
+		for _, v := range i.FileTypes() {
+			exts = append(exts, v.Ext)
+		}
+		m.Trace = append(m.Trace, fmt.Sprintf("not under prefix %q or file type not in %v", i.Spec.Prefix, exts))
+		return m, false
+	}
+	relative := strings.TrimPrefix(strings.TrimPrefix(filename, i.Spec.Prefix), "/")
+
+	var best *candidate
+	for _, c := range i.candidates(filename, relative, i.Spec.Includes, nil, nil, &m.Trace) {
+		c := c
+		if best == nil {
+			best = &c
+			if i.Spec.Match != MatchSpecific {
+				break
+			}
+			continue
+		}
+		if c.more(*best) {
+			best = &c
+		}
+	}
+	if best == nil {
+		m.Trace = append(m.Trace, "no include matched")
+		return m, false
+	}
+	m.Include, m.Chain = best.include, best.chain
+	m.Trace = append(m.Trace, fmt.Sprintf("selected %s", chainString(best.chain)))
+	return m, true
+}
+
+// 匹配的 include
+type candidate struct {
+	include     Include
+	chain       []Include
+	specificity int
+}
+
+// 嵌套更深，或者同一层级时 path 更具体
+func (c candidate) more(than candidate) bool {
+	if len(c.chain) != len(than.chain) {
+		return len(c.chain) > len(than.chain)
+	}
+	return c.specificity > than.specificity
+}
+
+// 按配置顺序返回匹配的 include，匹配嵌套 include 时，只返回最深的一层
+func (i IssueCreate) candidates(filename, relative string, includes []Include, parent *Include, chain []Include, trace *[]string) []candidate {
+	result := make([]candidate, 0)
+	for _, include := range includes {
+		current := append(append([]Include{}, chain...), include)
+		name := chainString(current)
+		ok, err := include.Matches(filename, relative)
+		if err != nil {
+			*trace = append(*trace, fmt.Sprintf("%s: %s", name, err.Error()))
+			continue
+		}
+		if !ok {
+			*trace = append(*trace, fmt.Sprintf("%s: not matched", name))
+			continue
+		}
+		if exclude, ok := include.excluded(filename, relative); ok {
+			*trace = append(*trace, fmt.Sprintf("%s: matched, but excluded by %s", name, exclude))
+			continue
+		}
+		*trace = append(*trace, fmt.Sprintf("%s: matched", name))
+
+		merged := include.inherit(parent)
+		children := i.candidates(filename, relative, include.Includes, &merged, current, trace)
+		if len(children) > 0 {
+			result = append(result, children...)
+			continue
+		}
+		result = append(result, candidate{include: merged, chain: current, specificity: include.specificity()})
+	}
+	return result
+}
+
+// 返回匹配的 exclude
+func (i Include) excluded(filename, relative string) (Include, bool) {
+	for _, v := range i.Exclude {
+		if ok, err := v.Matches(filename, relative); err == nil && ok {
+			return v, true
+		}
+	}
+	return Include{}, false
+}
+
+// 继承上级 include 的 title、groupBy，合并 labels
+func (i Include) inherit(parent *Include) Include {
+	if parent == nil {
+		return i
+	}
+	if i.Title == "" {
+		i.Title = parent.Title
+	}
+	if i.GroupBy == "" {
+		i.GroupBy = parent.GroupBy
+	}
+	labels := append([]string{}, parent.Labels...)
+	for _, v := range i.Labels {
+		exist := false
+		for _, l := range labels {
+			exist = exist || l == v
+		}
+		if !exist {
+			labels = append(labels, v)
+		}
+	}
+	i.Labels = labels
+	return i
+}
+
+// path 为文件路径中完整的一段或几段，末尾也可以是文件名去除扩展名的部分，如 _index 匹配 docs/_index.md
+// path 为空时匹配任意文件
+func pathMatch(p, filename string) bool {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return true
+	}
+	for start := 0; ; {
+		i := strings.Index(filename[start:], p)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(p)
+		if (i == 0 || filename[i-1] == '/') && (end == len(filename) || filename[end] == '/' || filename[end] == '.') {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+// doublestar 通配符匹配，** 匹配零或多层目录，其余每一段使用 path.Match
+func globMatch(pattern, name string) (bool, error) {
+	for _, p := range expandBraces(pattern) {
+		ok, err := globSegments(strings.Split(strings.Trim(p, "/"), "/"), strings.Split(name, "/"))
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func globSegments(pattern, name []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if ok, err := globSegments(pattern[1:], name[i:]); err != nil || ok {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(name) == 0 {
+			return false, nil
+		}
+		ok, err := path.Match(pattern[0], name[0])
+		if err != nil || !ok {
+			return false, err
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0, nil
+}
+
+// 展开 {a,b}，如 docs/{a,b}/*.md 展开为 docs/a/*.md 和 docs/b/*.md
+func expandBraces(pattern string) []string {
+	start := strings.Index(pattern, "{")
+	if start < 0 {
+		return []string{pattern}
+	}
+	end := strings.Index(pattern[start:], "}")
+	if end < 0 {
+		return []string{pattern}
+	}
+	end += start
+	result := make([]string, 0)
+	for _, v := range strings.Split(pattern[start+1:end], ",") {
+		result = append(result, expandBraces(pattern[:start]+v+pattern[end+1:])...)
+	}
+	return result
+}
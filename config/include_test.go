@@ -0,0 +1,57 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIssueCreate_SupportFile(t *testing.T) {
+	create := func(match string, includes ...Include) IssueCreate {
+		i := IssueCreate{}
+		i.Spec.Prefix = "content/en"
+		i.Spec.FileType = []string{"md"}
+		i.Spec.Match = match
+		i.Spec.Includes = includes
+		return i
+	}
+	ops := Include{Path: "docs/ops", Labels: []string{"ops"}}
+	docs := Include{
+		Path:    "docs/**/*.md",
+		Labels:  []string{"docs"},
+		Exclude: []Include{{Path: "docs/reference/**"}},
+		Includes: []Include{
+			{Path: `docs/tasks/[a-z]+/.*\.md`, Match: MatchRegex, Labels: []string{"tasks"}, Exclude: []Include{{Path: "legacy"}}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		create     IssueCreate
+		file       string
+		wantOK     bool
+		wantLabels []string
+	}{
+		{name: "path segment", create: create("", ops), file: "content/en/docs/ops/a.md", wantOK: true, wantLabels: []string{"ops"}},
+		{name: "path is not a prefix of a segment", create: create("", ops), file: "content/en/docs/ops-legacy/a.md", wantOK: false},
+		{name: "path without extension", create: create("", Include{Path: "_index"}), file: "content/en/docs/_index.md", wantOK: true},
+		{name: "file type", create: create("", ops), file: "content/en/docs/ops/a.html", wantOK: false},
+		{name: "glob", create: create("", docs), file: "content/en/docs/a/b/c.md", wantOK: true, wantLabels: []string{"docs"}},
+		{name: "glob exclude", create: create("", docs), file: "content/en/docs/reference/a.md", wantOK: false},
+		{name: "nested", create: create("", docs), file: "content/en/docs/tasks/ops/a.md", wantOK: true, wantLabels: []string{"docs", "tasks"}},
+		{name: "nested exclude", create: create("", docs), file: "content/en/docs/tasks/legacy/a.md", wantOK: true, wantLabels: []string{"docs"}},
+		{name: "brace", create: create("", Include{Path: "{docs,faq}/*.md"}), file: "content/en/faq/a.md", wantOK: true},
+		{name: "first match", create: create(MatchFirst, docs, ops), file: "content/en/docs/ops/a.md", wantOK: true, wantLabels: []string{"docs"}},
+		{name: "most specific match", create: create(MatchSpecific, docs, ops), file: "content/en/docs/ops/a.md", wantOK: true, wantLabels: []string{"ops"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.create.SupportFile(tt.file)
+			if ok != tt.wantOK {
+				t.Fatalf("SupportFile() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got.Labels, tt.wantLabels) && len(got.Labels)+len(tt.wantLabels) > 0 {
+				t.Errorf("SupportFile() labels = %v, want %v", got.Labels, tt.wantLabels)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LabelGroup 互斥的 label 组，issue 最多只能拥有组内的一个 label
+// 可以通过前缀或者显式列出组内的 label 来声明
+type LabelGroup struct {
+	Name   string   `yaml:"name"`
+	Prefix string   `yaml:"prefix"` // 以该前缀开头的 label 属于该组，如 status/
+	Labels []string `yaml:"labels"`
+}
+
+// Contains 判断 label 是否属于该组
+func (g LabelGroup) Contains(label string) bool {
+	if g.Prefix != "" && strings.HasPrefix(label, g.Prefix) {
+		return true
+	}
+	for _, v := range g.Labels {
+		if v == label {
+			return true
+		}
+	}
+	return false
+}
+
+// ExclusiveLabels 添加 added 中的 label 后，移除同组的其它 label
+// 如果 added 中有多个 label 属于同一组，以最后一个为准
+func (r Repository) ExclusiveLabels(labels []string, added ...string) []string {
+	result := append(make([]string, 0, len(labels)), labels...)
+	handled := make(map[int]bool)
+	for i := len(added) - 1; i >= 0; i-- {
+		a := added[i]
+		for k, g := range r.Spec.LabelGroups {
+			if handled[k] || !g.Contains(a) {
+				continue
+			}
+			handled[k] = true
+			kept := make([]string, 0, len(result))
+			for _, v := range result {
+				if v == a || !g.Contains(v) {
+					kept = append(kept, v)
+				}
+			}
+			result = kept
+		}
+	}
+	return result
+}
+
+// LabelConflicts 返回违反互斥规则的 label，key 为组名，value 为组内的 label，已排序
+func (r Repository) LabelConflicts(labels []string) map[string][]string {
+	conflicts := make(map[string][]string)
+	for _, g := range r.Spec.LabelGroups {
+		in := make([]string, 0)
+		for _, v := range labels {
+			if g.Contains(v) {
+				in = append(in, v)
+			}
+		}
+		if len(in) > 1 {
+			sort.Strings(in)
+			conflicts[g.Name] = in
+		}
+	}
+	return conflicts
+}
+
+// RequiredConflicts 检查添加 added 中的 label 时，是否会因为互斥组移除 required 中的 label
+// 如 stale 任务要求 status/waiting-for-pr，添加 status/stale 后该 label 会被移除，导致依赖它的指令和任务失效
+// removed 中显式移除的 label 视为预期的状态转换，不视为冲突
+func (r Repository) RequiredConflicts(required, added, removed []string) []string {
+	removes := make(map[string]bool)
+	for _, v := range removed {
+		removes[v] = true
+	}
+	messages := make([]string, 0)
+	for _, g := range r.Spec.LabelGroups {
+		for _, a := range added {
+			if !g.Contains(a) {
+				continue
+			}
+			for _, v := range required {
+				if v != a && !removes[v] && g.Contains(v) {
+					messages = append(messages, fmt.Sprintf("adding label %q removes required label %q of label group %q, list it in removeLabels or remove one of them from the group", a, v, g.Name))
+				}
+			}
+		}
+	}
+	return messages
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testGroups() Repository {
+	r := Repository{}
+	r.Spec.LabelGroups = []LabelGroup{
+		{Name: "status", Prefix: "status/"},
+		{Name: "priority", Labels: []string{"p0", "p1"}},
+	}
+	return r
+}
+
+func TestRepository_ExclusiveLabels(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels []string
+		added  []string
+		want   []string
+	}{
+		{name: "no group", labels: []string{"kind/page", "status/new"}, added: []string{"kind/page"}, want: []string{"kind/page", "status/new"}},
+		{name: "prefix", labels: []string{"kind/page", "status/new", "status/pending"}, added: []string{"status/pending"}, want: []string{"kind/page", "status/pending"}},
+		{name: "explicit", labels: []string{"p0", "p1", "status/new"}, added: []string{"p1"}, want: []string{"p1", "status/new"}},
+		{name: "last wins", labels: []string{"status/a", "status/b"}, added: []string{"status/a", "status/b"}, want: []string{"status/b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testGroups().ExclusiveLabels(tt.labels, tt.added...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExclusiveLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_LabelConflicts(t *testing.T) {
+	got := testGroups().LabelConflicts([]string{"status/b", "p0", "status/a", "kind/page"})
+	want := map[string][]string{"status": {"status/a", "status/b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LabelConflicts() = %v, want %v", got, want)
+	}
+}
+
+func TestRepository_RequiredConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		required []string
+		added    []string
+		removed  []string
+		want     int
+	}{
+		{name: "no group", required: []string{"kind/page"}, added: []string{"kind/bug"}},
+		{name: "removes required", required: []string{"status/waiting-for-pr"}, added: []string{"status/stale"}, want: 1},
+		{name: "explicit transition", required: []string{"status/pending"}, added: []string{"status/waiting-for-pr"}, removed: []string{"status/pending"}},
+		{name: "keep required", required: []string{"status/pending"}, added: []string{"status/pending"}},
+		{name: "other group", required: []string{"p0"}, added: []string{"status/stale"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testGroups().RequiredConflicts(tt.required, tt.added, tt.removed); len(got) != tt.want {
+				t.Errorf("RequiredConflicts() = %v, want %d conflicts", got, tt.want)
+			}
+		})
+	}
+}
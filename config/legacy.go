@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"strings"
+)
+
+// Legacy 旧版本的配置，即 README 和 instruction.md 中 full_repository_name、flows 格式的配置
+type Legacy struct {
+	FullRepositoryName string       `yaml:"full_repository_name"`
+	Maintains          []string     `yaml:"maintains"`
+	Flows              []LegacyFlow `yaml:"flows"`
+	LogDir             string       `yaml:"log_dir"`
+	LogFile            string       `yaml:"log_file"`
+	StdOutFile         string       `yaml:"std_out_file"`
+}
+
+// LegacyFlow 旧版本配置中的指令
+type LegacyFlow struct {
+	Name               string   `yaml:"name"`
+	Permission         []string `yaml:"permission"`
+	PermissionFeedback string   `yaml:"permission_feedback"`
+	Mention            string   `yaml:"mention"`
+	CurrentLabel       []string `yaml:"current_label"`
+	TargetLabel        []string `yaml:"target_label"`
+	SuccessFeedback    string   `yaml:"success_feedback"`
+	FailFeedback       string   `yaml:"fail_feedback"`
+	TargetLimit        int      `yaml:"target_limit"`
+	Limit              int      `yaml:"limit"`
+	LimitFeedback      string   `yaml:"limit_feedback"`
+	Close              bool     `yaml:"close"`
+
+	// project 相关的配置，已不再支持
+	CurrentColumnID int64  `yaml:"current_column_id"`
+	TargetColumnID  int64  `yaml:"target_column_id"`
+	TargetPosition  string `yaml:"target_position"`
+	ColumnFeedback  string `yaml:"column_feedback"`
+}
+
+// ParseLegacy 解析旧版本的配置，并返回被忽略的内容，如未知的字段
+// 首先严格解析，以便发现未知的字段，失败后再宽松解析，以便继续转换
+func ParseLegacy(data []byte) (Legacy, []string, error) {
+	legacy := Legacy{}
+	err := yaml.UnmarshalStrict(data, &legacy)
+	if err == nil {
+		return legacy, nil, nil
+	}
+	e, ok := err.(*yaml.TypeError)
+	if !ok {
+		return legacy, nil, err
+	}
+	legacy = Legacy{}
+	if err := yaml.Unmarshal(data, &legacy); err != nil {
+		return legacy, nil, err
+	}
+	warnings := make([]string, 0, len(e.Errors))
+	for _, v := range e.Errors {
+		warnings = append(warnings, fmt.Sprintf("%s, dropped", v))
+	}
+	return legacy, warnings, nil
+}
+
+// 旧版本的权限与现在的权限的对应关系
+var legacyPermissions = map[string]string{
+	"anyone":      "@anyone",
+	"member":      "@member",
+	"self":        "@assigner",
+	"maintainers": "@maintainer",
+}
+
+// 旧版本的占位符 @somebody 即现在的 @commenter
+func legacyText(s string) Text {
+	if s == "" {
+		return nil
+	}
+	return Text{"": strings.ReplaceAll(s, "@somebody", "@commenter")}
+}
+
+// Convert 转换为现在的配置，并返回无法转换的内容
+func (l Legacy) Convert() (Repository, []IssueComment, []string) {
+	warnings := make([]string, 0)
+
+	repository := Repository{}
+	repository.ApiVersion = APIVersion
+	repository.Kind = "Repository"
+	owner, name := l.FullRepositoryName, ""
+	if s := strings.SplitN(l.FullRepositoryName, "/", 2); len(s) == 2 {
+		owner, name = s[0], s[1]
+	} else if l.FullRepositoryName != "" {
+		warnings = append(warnings, fmt.Sprintf("bad full_repository_name %q, want owner/repository", l.FullRepositoryName))
+	}
+	repository.Metadata.Name = name
+	repository.Spec.Workspace.Owner = owner
+	repository.Spec.Workspace.Repository = name
+	if len(l.Maintains) > 0 {
+		warnings = append(warnings, "maintains is no longer supported, use spec.workspace.maintainerTeam of Repository instead")
+	}
+	if l.LogDir != "" || l.LogFile != "" || l.StdOutFile != "" {
+		warnings = append(warnings, "log_dir, log_file and std_out_file are no longer supported, logs are written to stdout")
+	}
+
+	comments := make([]IssueComment, 0, len(l.Flows))
+	for _, f := range l.Flows {
+		instruct := strings.TrimPrefix(f.Name, "/")
+		rule := Rule{
+			Instruct:           instruct,
+			Permissions:        make([]string, 0, len(f.Permission)),
+			PermissionFeedback: legacyText(f.PermissionFeedback),
+			Labels:             f.CurrentLabel,
+			LabelFeedback:      legacyText(f.FailFeedback),
+		}
+		for _, p := range f.Permission {
+			if v, ok := legacyPermissions[p]; ok {
+				rule.Permissions = append(rule.Permissions, v)
+			} else {
+				warnings = append(warnings, fmt.Sprintf("flow %s: unknown permission %q", f.Name, p))
+			}
+		}
+
+		action := Action{
+			AddLabels:          f.TargetLabel,
+			AddLabelsLimit:     f.TargetLimit,
+			LabelLimitFeedback: legacyText(f.LimitFeedback),
+			RemoveLabels:       f.CurrentLabel,
+			SuccessFeedback:    legacyText(f.SuccessFeedback),
+		}
+		if action.AddLabelsLimit == 0 {
+			action.AddLabelsLimit = f.Limit
+		}
+		if f.Close {
+			action.State = "closed"
+		}
+		switch f.Mention {
+		case "addition":
+			action.AddAssignees = []string{"@mention"}
+		case "remove":
+			action.RemoveAssignees = []string{"@mention"}
+		}
+		if f.CurrentColumnID != 0 || f.TargetColumnID != 0 || f.TargetPosition != "" || f.ColumnFeedback != "" {
+			warnings = append(warnings, fmt.Sprintf("flow %s: project card options are no longer supported", f.Name))
+		}
+
+		c := IssueComment{}
+		c.ApiVersion = APIVersion
+		c.Kind = "IssueComment"
+		c.Metadata.Name = fmt.Sprintf("issue-%s", instruct)
+		c.Spec.Rules = &rule
+		c.Spec.Action = &action
+		comments = append(comments, c)
+	}
+	return repository, comments, warnings
+}
@@ -0,0 +1,504 @@
+package config
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Problem 配置中的问题
+type Problem struct {
+	File string
+	// 行号，从 1 开始，0 表示无法确定行号
+	Line    int
+	Message string
+	// 警告不影响启动
+	Warning bool
+}
+
+func (p Problem) String() string {
+	level := "error"
+	if p.Warning {
+		level = "warning"
+	}
+	if p.File == "" {
+		return fmt.Sprintf("%s: %s", level, p.Message)
+	}
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", p.File, level, p.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", p.File, p.Line, level, p.Message)
+}
+
+// HasError 判断问题列表中是否有错误（而不仅仅是警告）
+func HasError(problems []Problem) bool {
+	for _, p := range problems {
+		if !p.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// 配置文件中的一段配置，即 YAML 的一个文档
+type document struct {
+	file string
+	// 整个配置文件的内容，以及该段配置在其中的序号，用于解析该段配置
+	stream string
+	index  int
+	// 起始行号（从 1 开始）及原文，仅用于报告问题所在的行
+	line int
+	data string
+	base Base
+	// 解析后的配置，类型取决于 base.Kind
+	value interface{}
+}
+
+// 返回第一个包含 text 的行号，找不到时返回起始行号
+func (d document) lineOf(text string) int {
+	for k, v := range strings.Split(d.data, "\n") {
+		if strings.Contains(v, text) {
+			return d.line + k
+		}
+	}
+	return d.line
+}
+
+// 解析该段配置，strict 为 true 时，未知的字段及重复的 key 视为错误
+// 错误信息中的行号为配置文件中的行号
+func (d document) decodeInto(v interface{}, strict bool) error {
+	dec := yaml.NewDecoder(strings.NewReader(d.stream))
+	for k := 0; k < d.index; k++ {
+		var skip interface{}
+		if err := dec.Decode(&skip); err != nil {
+			return err
+		}
+	}
+	dec.SetStrict(strict)
+	return dec.Decode(v)
+}
+
+// 拆分配置文件中的多个文档，并记录每段配置的起始行号
+// 只包含注释的文档会被忽略，出现语法错误时，之后的配置无法解析
+func splitDocuments(file, data string) ([]document, []Problem) {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	positions := documentPositions(data)
+	docs := make([]document, 0)
+	dec := yaml.NewDecoder(strings.NewReader(data))
+	for k := 0; ; k++ {
+		var v interface{}
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		d := document{file: file, stream: data, index: k, line: 1}
+		if k < len(positions) {
+			d.line, d.data = positions[k].line, positions[k].data
+		}
+		if err != nil {
+			return docs, d.problems(err)
+		}
+		if v != nil {
+			docs = append(docs, d)
+		}
+	}
+	return docs, nil
+}
+
+// 文档的起始行号及原文
+type position struct {
+	line int
+	data string
+}
+
+// 根据文档开始标记 --- 和结束标记 ... 查找每个文档的位置
+// 根据 YAML 规范，位于行首的标记不能出现在字符串等内容中，缩进的 --- 属于字符串内容
+func documentPositions(data string) []position {
+	positions := make([]position, 0)
+	lines := strings.Split(data, "\n")
+	start, open := 0, false
+	end := func(k int) {
+		if open {
+			positions = append(positions, position{line: start + 1, data: strings.Join(lines[start:k], "\n") + "\n"})
+		}
+		open = false
+	}
+	for k, line := range lines {
+		switch {
+		case isMarker(line, "---"):
+			end(k)
+			// 标记后的内容（如 --- |）属于该文档，用空格代替标记
+			lines[k] = "   " + line[3:]
+			start, open = k, true
+		case isMarker(line, "..."):
+			end(k)
+		case !open && !isBlank(line):
+			// 没有开始标记的文档
+			start, open = k, true
+		}
+	}
+	end(len(lines))
+	return positions
+}
+
+// 是否为位于行首的标记，标记之后可以是空格、制表符或行尾，如 --- # 注释、--- !tag
+func isMarker(line, marker string) bool {
+	return line == marker || strings.HasPrefix(line, marker+" ") || strings.HasPrefix(line, marker+"\t")
+}
+
+// 是否为空行、注释或指令（如 %YAML 1.1）
+func isBlank(line string) bool {
+	line = strings.TrimSpace(line)
+	return line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "%")
+}
+
+// yaml 错误信息中的行号
+var lineExp = regexp.MustCompile(`line (\d+): `)
+
+// 将 yaml 错误转换为问题列表，没有行号的错误使用起始行号
+func (d document) problems(err error) []Problem {
+	messages := []string{err.Error()}
+	if e, ok := err.(*yaml.TypeError); ok {
+		messages = e.Errors
+	}
+	problems := make([]Problem, 0, len(messages))
+	for _, message := range messages {
+		p := Problem{File: d.file, Line: d.line, Message: strings.TrimPrefix(message, "yaml: ")}
+		if m := lineExp.FindStringSubmatch(p.Message); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			p.Line = n
+			p.Message = strings.Replace(p.Message, m[0], "", 1)
+		}
+		problems = append(problems, p)
+	}
+	return problems
+}
+
+// 解析某个类型的配置
+// 首先严格解析，以便发现未知的字段，失败后再宽松解析，以便继续检查
+func (d *document) unmarshal(v interface{}) []Problem {
+	err := d.decodeInto(v, true)
+	if err == nil {
+		return nil
+	}
+	problems := d.problems(err)
+	if _, ok := err.(*yaml.TypeError); !ok {
+		return problems
+	}
+	_ = d.decodeInto(v, false)
+	return problems
+}
+
+// 当前支持的 apiVersion
+const APIVersion = "v1"
+
+// FileInclude 引用其它配置文件，即 Include 类型的配置
+type FileInclude struct {
+	Base `yaml:",inline"`
+	Spec struct {
+		// 文件路径，相对于当前文件所在的目录，支持目录和通配符
+		Files []string `yaml:"files"`
+	} `yaml:"spec"`
+}
+
+// Load 读取并解析配置，返回配置以及其中的问题
+// path 可以是文件、目录（读取其中所有的 .yaml、.yml 文件）或者通配符
+func Load(path string) (*Config, []Problem) {
+	files, err := resolve(path)
+	if err != nil {
+		return nil, []Problem{{File: path, Message: err.Error()}}
+	}
+	docs, problems := readDocuments(files, make(map[string]bool))
+	return build(docs, problems)
+}
+
+// Parse 解析配置内容，返回配置以及其中的问题
+// file 仅用于问题中的文件名，不支持 Include
+func Parse(file string, data []byte) (*Config, []Problem) {
+	data, problems := interpolate(file, data)
+	docs := make([]document, 0)
+	split, ps := splitDocuments(file, string(data))
+	problems = append(problems, ps...)
+	for _, d := range split {
+		decoded, ps := decode(d)
+		docs = append(docs, decoded...)
+		problems = append(problems, ps...)
+	}
+	return build(docs, problems)
+}
+
+// 根据路径获取配置文件列表，已排序
+func resolve(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err == nil && info.IsDir() {
+		files := make([]string, 0)
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, _ := filepath.Glob(filepath.Join(path, pattern))
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no config file in directory %s", path)
+		}
+		return files, nil
+	}
+	if err == nil {
+		return []string{path}, nil
+	}
+	matches, globErr := filepath.Glob(path)
+	if globErr != nil || len(matches) == 0 {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// 读取配置文件，并递归读取 Include 引用的文件
+// visited 用于避免重复读取同一个文件
+func readDocuments(files []string, visited map[string]bool) ([]document, []Problem) {
+	docs, problems := make([]document, 0), make([]Problem, 0)
+	for _, file := range files {
+		abs, _ := filepath.Abs(file)
+		if visited[abs] {
+			continue
+		}
+		visited[abs] = true
+
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			problems = append(problems, Problem{File: file, Message: err.Error()})
+			continue
+		}
+		data, ps := interpolate(file, data)
+		problems = append(problems, ps...)
+
+		split, ps := splitDocuments(file, string(data))
+		problems = append(problems, ps...)
+		for _, d := range split {
+			decoded, ps := decode(d)
+			problems = append(problems, ps...)
+			for _, v := range decoded {
+				include, ok := v.value.(FileInclude)
+				if !ok {
+					docs = append(docs, v)
+					continue
+				}
+				for _, pattern := range include.Spec.Files {
+					if !filepath.IsAbs(pattern) {
+						pattern = filepath.Join(filepath.Dir(file), pattern)
+					}
+					included, err := resolve(pattern)
+					if err != nil {
+						problems = append(problems, Problem{File: file, Line: v.lineOf(filepath.Base(pattern)), Message: fmt.Sprintf("include %s: %s", pattern, err.Error())})
+						continue
+					}
+					ds, ps := readDocuments(included, visited)
+					docs = append(docs, ds...)
+					problems = append(problems, ps...)
+				}
+			}
+		}
+	}
+	return docs, problems
+}
+
+// 环境变量，支持 ${NAME} 和 ${NAME:-默认值}，$${ 表示 ${ 本身
+var envExp = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// 替换配置中的环境变量，注释中的内容不会被替换
+// 未设置且没有默认值的环境变量视为错误
+func interpolate(file string, data []byte) ([]byte, []Problem) {
+	problems := make([]Problem, 0)
+	lines := strings.Split(string(data), "\n")
+	for k, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		lines[k] = envExp.ReplaceAllStringFunc(line, func(s string) string {
+			if strings.HasPrefix(s, "$$") {
+				return s[1:]
+			}
+			m := envExp.FindStringSubmatch(s)
+			if v, ok := os.LookupEnv(m[1]); ok {
+				return v
+			}
+			if m[2] != "" {
+				return m[3]
+			}
+			problems = append(problems, Problem{File: file, Line: k + 1, Message: fmt.Sprintf("environment variable %s is not set", m[1])})
+			return ""
+		})
+	}
+	return []byte(strings.Join(lines, "\n")), problems
+}
+
+// 旧版本配置中的字段，用于提示如何迁移
+var legacyFields = map[string]string{
+	"full_repository_name": "spec.workspace.owner and spec.workspace.repository of Repository",
+	"flows":                "IssueComment documents",
+	"name":                 "spec.rules.instruct",
+	"permission":           "spec.rules.permissions",
+	"permission_feedback":  "spec.rules.permissionFeedback",
+	"current_label":        "spec.rules.labels and spec.action.removeLabels",
+	"target_label":         "spec.action.addLabels",
+	"target_limit":         "spec.action.addLabelsLimit",
+	"limit":                "spec.action.addLabelsLimit",
+	"limit_feedback":       "spec.action.labelLimitFeedback",
+	"success_feedback":     "spec.action.successFeedback",
+	"fail_feedback":        "spec.rules.labelFeedback",
+	"close":                "spec.action.state: closed",
+	"mention":              "spec.action.addAssignees or spec.action.removeAssignees with @mention",
+}
+
+// 未知字段的错误信息，如 field current_label not found in type config.Rule
+var unknownFieldExp = regexp.MustCompile(`^field (\S+) not found in type`)
+
+// 解析一段配置，旧版本的配置会被转换为多段配置
+func decode(d document) ([]document, []Problem) {
+	base := Base{}
+	if err := d.decodeInto(&base, false); err != nil {
+		return nil, d.problems(err)
+	}
+	d.base = base
+
+	problems := make([]Problem, 0)
+	switch {
+	case base.Kind == "":
+		return d.legacy()
+	case base.ApiVersion == "":
+		problems = append(problems, Problem{File: d.file, Line: d.line, Warning: true, Message: fmt.Sprintf("missing apiVersion, assuming %q", APIVersion)})
+	case base.ApiVersion != APIVersion:
+		return nil, []Problem{{File: d.file, Line: d.lineOf("apiVersion:"), Message: fmt.Sprintf("unsupported apiVersion %q, only %q is supported, run `issue-man convert` to migrate legacy config", base.ApiVersion, APIVersion)}}
+	}
+
+	var ps []Problem
+	switch base.Kind {
+	// Repository 的配置
+	case "Repository":
+		tmp := Repository{}
+		ps = d.unmarshal(&tmp)
+		// 模板文件的路径相对于当前文件所在的目录
+		for k, pattern := range tmp.Spec.Templates {
+			if !filepath.IsAbs(pattern) {
+				tmp.Spec.Templates[k] = filepath.Join(filepath.Dir(d.file), pattern)
+			}
+		}
+		d.value = tmp
+	// IssueCreate 的配置
+	case "IssueCreate":
+		tmp := IssueCreate{}
+		ps = d.unmarshal(&tmp)
+		d.value = tmp
+	// IssueComment 的配置
+	case "IssueComment":
+		tmp := IssueComment{}
+		ps = d.unmarshal(&tmp)
+		d.value = tmp
+	// Job 的配置
+	case "Job":
+		tmp := Job{}
+		ps = d.unmarshal(&tmp)
+		d.value = tmp
+	// Workflow 的配置
+	case "Workflow":
+		tmp := Workflow{}
+		ps = d.unmarshal(&tmp)
+		d.value = tmp
+	// 引用其它配置文件
+	case "Include":
+		tmp := FileInclude{}
+		ps = d.unmarshal(&tmp)
+		d.value = tmp
+	// 不支持类型的配置
+	default:
+		return nil, append(problems, Problem{File: d.file, Line: d.lineOf("kind:"), Message: fmt.Sprintf("unsupported kind %q", base.Kind)})
+	}
+
+	// 提示旧版本字段的新写法
+	for k, p := range ps {
+		if m := unknownFieldExp.FindStringSubmatch(p.Message); m != nil && legacyFields[m[1]] != "" {
+			ps[k].Message += fmt.Sprintf(", use %s instead", legacyFields[m[1]])
+		}
+	}
+	return []document{d}, append(problems, ps...)
+}
+
+// 解析旧版本的配置，即 README 中 full_repository_name、flows 格式的配置
+func (d document) legacy() ([]document, []Problem) {
+	l := Legacy{}
+	if err := d.decodeInto(&l, false); err != nil {
+		return nil, d.problems(err)
+	}
+	if l.FullRepositoryName == "" && len(l.Flows) == 0 {
+		return nil, []Problem{{File: d.file, Line: d.line, Message: "missing kind"}}
+	}
+
+	problems := []Problem{{File: d.file, Line: d.line, Warning: true, Message: "legacy config format is deprecated, run `issue-man convert` to migrate"}}
+	repository, comments, warnings := l.Convert()
+	for _, w := range warnings {
+		problems = append(problems, Problem{File: d.file, Line: d.line, Warning: true, Message: w})
+	}
+
+	docs := make([]document, 0, len(comments)+1)
+	if l.FullRepositoryName != "" {
+		v := d
+		v.base = repository.Base
+		v.value = repository
+		docs = append(docs, v)
+	}
+	for _, c := range comments {
+		v := d
+		v.base = c.Base
+		v.value = c
+		docs = append(docs, v)
+	}
+	return docs, problems
+}
+
+// 将解析后的配置合并为 Config，并检查
+func build(docs []document, problems []Problem) (*Config, []Problem) {
+	conf := &Config{}
+	conf.IssueComments = make([]IssueComment, 0)
+	conf.Jobs = make([]Job, 0)
+	conf.Workflows = make([]Workflow, 0)
+
+	for k := range docs {
+		d := &docs[k]
+		switch v := d.value.(type) {
+		case Repository:
+			v.Base = d.base
+			conf.Repository = v
+		case IssueCreate:
+			v.Base = d.base
+			conf.IssueCreate = v
+		case IssueComment:
+			v.Base = d.base
+			conf.IssueComments = append(conf.IssueComments, v)
+		case Job:
+			v.Base = d.base
+			conf.Jobs = append(conf.Jobs, v)
+		case Workflow:
+			v.Base = d.base
+			conf.Workflows = append(conf.Workflows, v)
+		}
+	}
+
+	// 将工作流程编译为指令
+	conf.CompileWorkflows()
+
+	problems = append(problems, validate(conf, docs)...)
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].File != problems[j].File {
+			return problems[i].File < problems[j].File
+		}
+		return problems[i].Line < problems[j].Line
+	})
+	return conf, problems
+}
@@ -0,0 +1,253 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	data := `apiVersion: "v1"
+kind: "Repository"
+spec:
+  workspace:
+    owner: "o"
+    labels:
+    - name: "status/pending"
+    detection:
+      at: "25:00"
+---
+apiVersion: "v1"
+kind: "IssueComment"
+metadata:
+  name: "a"
+spec:
+  rules:
+    instruct: "accept"
+    permissions:
+    - "@nobody"
+    labels:
+    - "status/pending"
+  action:
+    addLabel:
+    - "status/pending"
+---
+apiVersion: "v1"
+kind: "IssueComment"
+metadata:
+  name: "b"
+spec:
+  rules:
+    instruct: "accept"
+    permissions:
+    - "@maintainer"
+    labels:
+    - "status/unknown"
+  action: {}
+---
+kind: "Unknown"
+apiVersion: "v1"
+---
+# 只有注释
+`
+	conf, problems := Parse("test.yaml", []byte(data))
+	got := make([]string, 0, len(problems))
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	want := []string{
+		`test.yaml:9: error: bad detection.at "25:00", want HH:MM`,
+		`test.yaml:19: error: unknown permission "@nobody", want one of [@anyone @assigner @maintainer @member]`,
+		`test.yaml:23: error: field addLabel not found in type config.Action`,
+		`test.yaml:32: error: instruct "accept" is already defined by a`,
+		`test.yaml:36: warning: label "status/unknown" is not declared in spec.workspace.labels`,
+		`test.yaml:39: error: unsupported kind "Unknown"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() problems =\n%v\nwant\n%v", got, want)
+	}
+	if len(conf.IssueComments) != 2 || conf.Repository.Spec.Workspace.Owner != "o" {
+		t.Errorf("Parse() conf = %+v", conf)
+	}
+}
+
+func TestParse_legacy(t *testing.T) {
+	data := `full_repository_name: "gorda/gorda.io"
+flows:
+  - name: "/accept"
+    permission:
+      - "maintainers"
+      - "member"
+    mention: "addition"
+    current_label:
+      - "status/spending"
+    target_label:
+      - "status/waiting-for-pr"
+    limit: 3
+    success_feedback: "Thank you @somebody, this issue had been assigned to you."
+`
+	conf, problems := Parse("legacy.yaml", []byte(data))
+	if HasError(problems) {
+		t.Fatalf("Parse() problems = %v", problems)
+	}
+	if conf.Repository.Spec.Workspace.Owner != "gorda" || conf.Repository.Spec.Workspace.Repository != "gorda.io" {
+		t.Errorf("Parse() workspace = %+v", conf.Repository.Spec.Workspace)
+	}
+	if len(conf.IssueComments) != 1 {
+		t.Fatalf("Parse() got %d instructs, want 1", len(conf.IssueComments))
+	}
+	c := conf.IssueComments[0]
+	if c.Spec.Rules.Instruct != "accept" ||
+		!reflect.DeepEqual(c.Spec.Rules.Permissions, []string{"@maintainer", "@member"}) ||
+		!reflect.DeepEqual(c.Spec.Action.RemoveLabels, []string{"status/spending"}) ||
+		!reflect.DeepEqual(c.Spec.Action.AddAssignees, []string{"@mention"}) ||
+		c.Spec.Action.AddLabelsLimit != 3 ||
+		c.Spec.Action.SuccessFeedback.Get() != "Thank you @commenter, this issue had been assigned to you." {
+		t.Errorf("Parse() instruct = %+v, %+v", c.Spec.Rules, c.Spec.Action)
+	}
+}
+
+func TestParse_documents(t *testing.T) {
+	if err := os.Setenv("ISSUE_MAN_TEST_OWNER", "o"); err != nil {
+		t.Fatal(err)
+	}
+	data := `apiVersion: "v1"
+kind: "Repository"
+spec:
+  workspace:
+    owner: "${ISSUE_MAN_TEST_OWNER}"
+    repository: "${ISSUE_MAN_TEST_UNSET:-r}"
+  messages:
+    body.files:
+      "": |
+        ---
+        $${literal}
+--- # 第二段配置
+apiVersion: "v1"
+kind: "IssueComment"
+spec:
+  rules:
+    instruct: "${ISSUE_MAN_TEST_UNSET}"
+  action: {}
+`
+	conf, problems := Parse("test.yaml", []byte(data))
+	got := make([]string, 0, len(problems))
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	want := []string{
+		"test.yaml:15: error: missing required field spec.rules.instruct",
+		"test.yaml:17: error: environment variable ISSUE_MAN_TEST_UNSET is not set",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() problems = %v, want %v", got, want)
+	}
+	workspace := conf.Repository.Spec.Workspace
+	if workspace.Owner != "o" || workspace.Repository != "r" {
+		t.Errorf("Parse() workspace = %+v", workspace)
+	}
+	if got := conf.Repository.Spec.Messages["body.files"].Get(); got != "---\n${literal}\n" {
+		t.Errorf("Parse() message = %q", got)
+	}
+}
+
+func TestLoad_templates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"templates/ok.tmpl":  `{{define "ok"}}@{{.Commenter}} ok{{end}}`,
+		"templates/bad.tmpl": `{{end}}`,
+		"config.yaml": `apiVersion: "v1"
+kind: "Repository"
+spec:
+  workspace:
+    owner: "o"
+  templates:
+  - "templates/*.tmpl"
+---
+apiVersion: "v1"
+kind: "IssueComment"
+spec:
+  rules:
+    instruct: "accept"
+  action:
+    successFeedback: '{{template "ok" .}}'
+    failFeedback:
+      en: '{{.Commenter'
+`,
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 在其它目录中读取配置，模板文件的路径相对于配置文件所在的目录
+	conf, problems := Load(filepath.Join(dir, "config.yaml"))
+	got := make([]string, 0, len(problems))
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	file := filepath.Join(dir, "config.yaml")
+	want := []string{
+		file + `:6: error: parse template ` + filepath.Join(dir, "templates/bad.tmpl") + `: template: bad.tmpl:1: unexpected {{end}}`,
+		file + `:16: error: bad failFeedback: language "en": template: feedback:1: unclosed action`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() problems =\n%v\nwant\n%v", got, want)
+	}
+	if want := []string{filepath.Join(dir, "templates/*.tmpl")}; !reflect.DeepEqual(conf.Repository.Spec.Templates, want) {
+		t.Errorf("Load() templates = %v, want %v", conf.Repository.Spec.Templates, want)
+	}
+}
+
+func TestParse_markers(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "document end",
+			data: "# 只有注释\n---\napiVersion: \"v1\"\nkind: \"Repository\"\nspec:\n  workspace:\n    owner: \"o\"\n...\n---\napiVersion: \"v1\"\nkind: \"IssueComment\"\nspec:\n  rules:\n    instruct: \"accept\"\n...\n",
+			want: []string{"test.yaml:12: error: missing required field spec.action"},
+		},
+		{
+			name: "tag and comment",
+			data: "--- !config # 仓库配置\napiVersion: \"v1\"\nkind: \"Repository\"\nunknown: 1\nspec:\n  workspace:\n    owner: \"o\"\n",
+			want: []string{"test.yaml:4: error: field unknown not found in type config.Repository"},
+		},
+		{
+			name: "block scalar",
+			data: "--- |\n  ---\n  not a document\n---\napiVersion: \"v1\"\nkind: \"Repository\"\n",
+			want: []string{"test.yaml:1: error: cannot unmarshal !!str `---\nnot...` into config.Base", "test.yaml:4: error: missing required field spec.workspace.owner"},
+		},
+		{
+			name: "syntax error",
+			data: "apiVersion: \"v1\"\nkind: \"Repository\"\nspec:\n  workspace:\n    owner: \"o\"\n---\nkind: [\n",
+			want: []string{"test.yaml:7: error: did not find expected node content"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, problems := Parse("test.yaml", []byte(tt.data))
+			got := make([]string, 0, len(problems))
+			for _, p := range problems {
+				got = append(got, p.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() problems =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
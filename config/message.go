@@ -0,0 +1,357 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// 默认的语言标签前缀，如 issue 带有 lang/ja 标签，则优先使用日语
+const DefaultLanguageLabelPrefix = "lang/"
+
+// Text 是一段支持多语言的文本，key 为语言，如 zh、en、ja
+// 配置文件中既可以写为字符串，也可以写为 语言: 文本 的形式：
+//
+//	successFeedback: "Thanks @commenter"
+//	successFeedback:
+//	  zh: "感谢 @commenter"
+//	  en: "Thanks @commenter"
+//
+// 写为字符串时，key 为空，表示未指定语言，作为所有语言的兜底
+type Text map[string]string
+
+// UnmarshalYAML 同时支持字符串和 map 两种写法
+func (t *Text) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	s := ""
+	if err := unmarshal(&s); err == nil {
+		*t = Text{"": s}
+		return nil
+	}
+	m := make(map[string]string)
+	if err := unmarshal(&m); err != nil {
+		return err
+	}
+	*t = m
+	return nil
+}
+
+// MarshalYAML 仅有未指定语言的文本时，输出为字符串
+func (t Text) MarshalYAML() (interface{}, error) {
+	if len(t) == 1 {
+		if s, ok := t[""]; ok {
+			return s, nil
+		}
+	}
+	return map[string]string(t), nil
+}
+
+// Get 按照 langs 的顺序查找文本
+// 都找不到时，依次使用未指定语言的文本、按语言排序后的第一个文本
+func (t Text) Get(langs ...string) string {
+	for _, lang := range langs {
+		if s, ok := t[lang]; ok {
+			return s
+		}
+	}
+	if s, ok := t[""]; ok {
+		return s
+	}
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	sort.Strings(keys)
+	return t[keys[0]]
+}
+
+// IsEmpty 是否没有任何文本
+func (t Text) IsEmpty() bool {
+	for _, v := range t {
+		if v != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Languages 根据用户偏好、issue label 和项目配置，生成语言的查找顺序
+// 顺序为：用户偏好 > issue 的语言 label > 项目默认语言
+// 对于 zh-CN 这种带地区的语言，会在其后追加 zh
+func (r Repository) Languages(login string, labels []string) []string {
+	langs := make([]string, 0)
+	if lang, ok := r.Spec.UserLanguages[login]; ok && login != "" {
+		langs = append(langs, lang)
+	}
+	prefix := r.Spec.LanguageLabelPrefix
+	if prefix == "" {
+		prefix = DefaultLanguageLabelPrefix
+	}
+	for _, label := range labels {
+		if strings.HasPrefix(label, prefix) {
+			langs = append(langs, strings.TrimPrefix(label, prefix))
+		}
+	}
+	if r.Spec.Language != "" {
+		langs = append(langs, r.Spec.Language)
+	}
+
+	// 追加不带地区的语言，并去重
+	chain := make([]string, 0, len(langs))
+	exist := make(map[string]bool)
+	for _, lang := range langs {
+		for _, v := range []string{lang, strings.SplitN(lang, "-", 2)[0]} {
+			if v == "" || exist[v] {
+				continue
+			}
+			exist[v] = true
+			chain = append(chain, v)
+		}
+	}
+	return chain
+}
+
+// Message 获取内置文本，Repository.Spec.Messages 中的配置会覆盖内置文本
+func (r Repository) Message(key string, langs ...string) string {
+	return r.MessageText(key).Get(langs...)
+}
+
+// MessageText 获取内置文本的所有语言，用于需要之后再选择语言的场景，如指令的 feedback
+func (r Repository) MessageText(key string) Text {
+	text := make(Text)
+	for k, v := range DefaultMessages[key] {
+		text[k] = v
+	}
+	for k, v := range r.Spec.Messages[key] {
+		text[k] = v
+	}
+	return text
+}
+
+// 内置文本，部分文本为 fmt 格式
+// key 为空的文本与之前的版本保持一致
+var DefaultMessages = map[string]Text{
+	// issue body
+	"body.requirement": {
+		"":   "### Requirement\n\n翻译人员信息登录：%s\n\n翻译指南：%s\n\n",
+		"zh": "### 要求\n\n翻译人员信息登录：%s\n\n翻译指南：%s\n\n",
+		"en": "### Requirement\n\nTranslator registration: %s\n\nTranslation guide: %s\n\n",
+	},
+	"body.source": {
+		"":   "Source",
+		"zh": "原文",
+		"en": "Source",
+	},
+	"body.translate": {
+		"":   "Translate",
+		"zh": "译文",
+		"en": "Translate",
+	},
+	// 按文件分类时的 body，参数依次为标题、URL、History、File
+	"body.file": {
+		"":   "## %s\n\nURL：%s\n\nHistory：%s\n\nFile：%s\n\n",
+		"zh": "## %s\n\n网页：%s\n\n历史：%s\n\n文件：%s\n\n",
+		"en": "## %s\n\nURL: %s\n\nHistory: %s\n\nFile: %s\n\n",
+	},
+	// 按目录分类时的 body，参数依次为标题、URL、History
+	"body.directory": {
+		"":   "## %s\n\nURL：%s\n\nHistory：%s\n\n",
+		"zh": "## %s\n\n网页：%s\n\n历史：%s\n\n",
+		"en": "## %s\n\nURL: %s\n\nHistory: %s\n\n",
+	},
+	"body.files": {
+		"":   "Files：\n",
+		"zh": "文件：\n",
+		"en": "Files:\n",
+	},
+	// 被忽略的上游改动，每个改动一行
+	"body.ignored": {
+		"":   "## Ignored\n\n",
+		"zh": "## 已忽略的改动\n\n",
+		"en": "## Ignored changes\n\n",
+	},
+
+	// 延期指令，issue 没有对应 job 的截止时间，如缺少 job 要求的 label
+	"delay.noDeadline": {
+		"":   "@commenter，该任务当前没有可以延期的截止时间。",
+		"en": "@commenter, this issue has no deadline to extend.",
+	},
+
+	// 撤销指令，参数依次为指令名、req id
+	"undo.done": {
+		"":   "已撤销指令 `/%s`（`%s`）做出的改动。",
+		"en": "Reverted the changes made by `/%s` (`%s`).",
+	},
+	"undo.notFound": {
+		"":   "没有找到可以撤销的改动。",
+		"en": "No change to revert.",
+	},
+	"undo.changed": {
+		"":   "无法撤销指令 `/%s`（`%s`）做出的改动，该 issue 在此之后已被修改，请联系 maintainer 手动处理。",
+		"en": "Can not revert the changes made by `/%s` (`%s`), the issue has been changed since then. Please ask a maintainer for help.",
+	},
+
+	// 内置的 /help 指令
+	"help.header": {
+		"":   "| 指令 | 权限 | 需要的标签 | 效果 |\n| --- | --- | --- | --- |\n",
+		"en": "| Instruction | Permissions | Required labels | Effect |\n| --- | --- | --- | --- |\n",
+	},
+	"help.builtin": {
+		"":   "\n内置指令：`/help` 显示本帮助，`/status` 显示 issue 的当前状态以及你可以执行的指令，`/explain /<指令>` 预览指令的执行结果。",
+		"en": "\nBuilt-in: `/help` shows this help, `/status` shows the state of the issue and the instructions you can run now, `/explain /<instruction>` previews an instruction.",
+	},
+	"help.none": {
+		"":   "无",
+		"en": "none",
+	},
+	// 以下为指令效果的描述，参数为标签、人员列表等
+	"help.addLabels": {
+		"":   "添加标签 %s",
+		"en": "add labels %s",
+	},
+	"help.removeLabels": {
+		"":   "移除标签 %s",
+		"en": "remove labels %s",
+	},
+	"help.addAssignees": {
+		"":   "添加负责人 %s",
+		"en": "add assignees %s",
+	},
+	"help.removeAssignees": {
+		"":   "移除负责人 %s",
+		"en": "remove assignees %s",
+	},
+	"help.state": {
+		"":   "将 issue 状态改为 %s",
+		"en": "set the issue state to %s",
+	},
+	// 参数依次为 job 名、天数
+	"help.delay": {
+		"":   "将 %s 的截止时间延长 %d 天",
+		"en": "extend the deadline of %s by %d days",
+	},
+	"help.undo": {
+		"":   "撤销指令做出的改动",
+		"en": "revert changes made by instructions",
+	},
+
+	// 内置的 /status 指令，参数依次为状态、标签、负责人
+	"status.current": {
+		"":   "当前状态：%s\n\n标签：%s\n\n负责人：%s\n\n",
+		"en": "State: %s\n\nLabels: %s\n\nAssignees: %s\n\n",
+	},
+	// 参数依次为评论者、指令列表
+	"status.available": {
+		"":   "@%s 当前可以执行的指令：%s",
+		"en": "@%s, instructions you can run now: %s",
+	},
+	"status.unavailable": {
+		"":   "@%s 当前没有可以执行的指令。",
+		"en": "@%s, there is no instruction you can run now.",
+	},
+
+	// 内置的 /explain 指令
+	"explain.usage": {
+		"":   "用法：`/explain /<指令> [@提及的人]`，预览指令的执行结果，不会对 issue 做出任何改动。",
+		"en": "Usage: `/explain /<instruction> [@mention]`, previews an instruction without changing the issue.",
+	},
+	"explain.unknown": {
+		"":   "未知的指令 `/%s`，可以通过 `/help` 查看所有指令。",
+		"en": "Unknown instruction `/%s`, see `/help` for all instructions.",
+	},
+	"explain.title": {
+		"":   "指令 `/%s` 的执行预览，不会对 issue 做出任何改动：\n\n",
+		"en": "Preview of `/%s`, the issue is not changed:\n\n",
+	},
+	"explain.header": {
+		"":   "| 检查 | 结果 |\n| --- | --- |\n",
+		"en": "| Check | Result |\n| --- | --- |\n",
+	},
+	"explain.pass": {
+		"":   "通过",
+		"en": "pass",
+	},
+	"explain.fail": {
+		"":   "未通过",
+		"en": "fail",
+	},
+	"explain.labels": {
+		"":   "标签：%s\n\n",
+		"en": "Labels: %s\n\n",
+	},
+	"explain.assignees": {
+		"":   "负责人：%s\n\n",
+		"en": "Assignees: %s\n\n",
+	},
+	// 参数依次为当前状态、执行后的状态
+	"explain.state": {
+		"":   "状态：%s → %s\n\n",
+		"en": "State: %s → %s\n\n",
+	},
+	"explain.feedback": {
+		"":   "将会发送的反馈：\n\n",
+		"en": "Feedback to be posted:\n\n",
+	},
+	"explain.noFeedback": {
+		"":   "不会发送反馈。",
+		"en": "No feedback would be posted.",
+	},
+
+	// 上游文件变动时的 comment
+	"sync.pullRequest": {
+		"":   "Pull Request: %s",
+		"zh": "Pull Request：%s",
+	},
+	"sync.diff": {
+		"":   "Diff: %s",
+		"zh": "差异：%s",
+	},
+	"sync.commit": {
+		"":   "Commit SHA: %s",
+		"zh": "Commit SHA：%s",
+	},
+	"sync.mergedAt": {
+		"":   "Merged At: %s",
+		"zh": "合并时间：%s",
+	},
+	"sync.filename": {
+		"":   "Filename: %s",
+		"zh": "文件：%s",
+	},
+	"sync.previousFilename": {
+		"":   "Previous Filename: %s",
+		"zh": "原文件：%s",
+	},
+	"sync.status": {
+		"":   "Status: %s",
+		"zh": "状态：%s",
+	},
+	"sync.assignees": {
+		"":   "Assignees: ",
+		"zh": "负责人：",
+	},
+	// issue 已关闭时，参见 detection.closed
+	"sync.reopened": {
+		"":   "上游文件有变动，已重新打开该 issue。",
+		"en": "Reopened because the upstream files changed.",
+	},
+	// update issue 的 title，参数为原 issue 的 title
+	"sync.updateTitle": {
+		"": "%s (update)",
+	},
+	// update issue 的 comment，参数依次为原 issue 的 number、上游改动的链接
+	"sync.update": {
+		"":   "#%d 已完成翻译，原文在此之后的改动：%s",
+		"en": "Translated in #%d. Upstream changes since then: %s",
+	},
+	// 汇总的 comment，一次检测中每个 issue 只发送一条
+	"sync.summary": {
+		"":   "上游文件有变动（%d 个）：",
+		"en": "Upstream files changed (%d):",
+	},
+	"sync.table": {
+		"":   "| 文件 | 状态 | Pull Request / Commit | 差异 | 行数 |",
+		"en": "| File | Status | Pull Request / Commit | Diff | Lines |",
+	},
+}
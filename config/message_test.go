@@ -0,0 +1,77 @@
+package config
+
+import (
+	"gopkg.in/yaml.v2"
+	"reflect"
+	"testing"
+)
+
+func TestText_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Text
+	}{
+		{name: "string", data: `feedback: "hi @commenter"`, want: Text{"": "hi @commenter"}},
+		{name: "map", data: "feedback:\n  zh: 你好\n  en: hi", want: Text{"zh": "你好", "en": "hi"}},
+		{name: "missing", data: `other: 1`, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := struct {
+				Feedback Text `yaml:"feedback"`
+			}{}
+			if err := yaml.Unmarshal([]byte(tt.data), &v); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(v.Feedback, tt.want) {
+				t.Errorf("UnmarshalYAML() = %v, want %v", v.Feedback, tt.want)
+			}
+		})
+	}
+}
+
+func TestText_Get(t *testing.T) {
+	text := Text{"": "default", "zh": "中文", "en": "english"}
+	if got := text.Get("ja", "en"); got != "english" {
+		t.Errorf("Get() = %v, want english", got)
+	}
+	if got := text.Get("ja"); got != "default" {
+		t.Errorf("Get() = %v, want default", got)
+	}
+	if got := (Text{"zh": "中文", "en": "english"}).Get("ja"); got != "english" {
+		t.Errorf("Get() = %v, want first sorted", got)
+	}
+	if got := Text(nil).Get("zh"); got != "" {
+		t.Errorf("Get() = %v, want empty", got)
+	}
+}
+
+func TestRepository_Languages(t *testing.T) {
+	r := Repository{}
+	r.Spec.Language = "zh-CN"
+	r.Spec.UserLanguages = map[string]string{"gorda": "en"}
+
+	got := r.Languages("gorda", []string{"kind/page", "lang/ja"})
+	want := []string{"en", "ja", "zh-CN", "zh"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Languages() = %v, want %v", got, want)
+	}
+	if got := r.Languages("", nil); !reflect.DeepEqual(got, []string{"zh-CN", "zh"}) {
+		t.Errorf("Languages() = %v", got)
+	}
+}
+
+func TestRepository_Message(t *testing.T) {
+	r := Repository{}
+	r.Spec.Messages = map[string]Text{"body.files": {"ja": "ファイル：\n"}}
+	if got := r.Message("body.files", "ja"); got != "ファイル：\n" {
+		t.Errorf("Message() = %q", got)
+	}
+	if got := r.Message("body.files", "en"); got != "Files:\n" {
+		t.Errorf("Message() = %q", got)
+	}
+	if got := r.Message("body.files"); got != DefaultMessages["body.files"][""] {
+		t.Errorf("Message() = %q", got)
+	}
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"strings"
+	"time"
+)
+
+// 默认每天 00:00 检测
+const DefaultSchedule = "0 0 * * *"
+
+// 检测上游变动的方式
+const (
+	DetectionPR     = "pr"
+	DetectionCommit = "commit"
+)
+
+// 检测进度的保存位置
+const (
+	CheckpointStore = "store"
+	CheckpointIssue = "issue"
+)
+
+// 上游文件有变动，但对应的 issue 已关闭时的处理方式
+const (
+	ClosedReopen = "reopen"
+	ClosedUpdate = "update"
+	ClosedIgnore = "ignore"
+)
+
+// 标准的 5 段式 cron 格式，同时支持 @daily、@every 1h 等写法
+var scheduleParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// ParseSchedule 解析 cron 格式的执行时间
+func ParseSchedule(spec string) (cron.Schedule, error) {
+	return scheduleParser.Parse(spec)
+}
+
+// 将废弃的 HH:MM 格式转换为 cron 格式
+func atToSchedule(at string) (string, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(at))
+	if err != nil {
+		return "", fmt.Errorf("bad detection.at %q, want HH:MM", at)
+	}
+	return fmt.Sprintf("%d %d * * *", t.Minute(), t.Hour()), nil
+}
+
+// DetectionSchedule 返回同步检测的执行时间
+// 优先使用 detection.schedule，其次是 detection.at，都未配置时使用默认值
+func (r Repository) DetectionSchedule() (string, error) {
+	detection := r.Spec.Workspace.Detection
+	if detection.Schedule != "" {
+		return detection.Schedule, nil
+	}
+	if detection.At != "" {
+		return atToSchedule(detection.At)
+	}
+	return DefaultSchedule, nil
+}
+
+// DetectionMode 返回检测上游变动的方式，未配置时为 DetectionPR
+func (r Repository) DetectionMode() string {
+	if r.Spec.Workspace.Detection.Mode == "" {
+		return DetectionPR
+	}
+	return r.Spec.Workspace.Detection.Mode
+}
+
+// DetectionBranches 返回需要检测的分支，第一个为 source.branch
+func (r Repository) DetectionBranches() []string {
+	branches := []string{r.Spec.Source.Branch}
+	seen := map[string]bool{r.Spec.Source.Branch: true}
+	for _, v := range r.Spec.Workspace.Detection.Branches {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			branches = append(branches, v)
+		}
+	}
+	return branches
+}
+
+// DetectionCheckpoint 返回检测进度的保存位置，未配置时为 CheckpointStore
+func (r Repository) DetectionCheckpoint() string {
+	if r.Spec.Workspace.Detection.Checkpoint == "" {
+		return CheckpointStore
+	}
+	return r.Spec.Workspace.Detection.Checkpoint
+}
+
+// DetectionClosed 返回 issue 已关闭时的处理方式，未配置时为 ClosedReopen
+func (r Repository) DetectionClosed() string {
+	if r.Spec.Workspace.Detection.Closed == "" {
+		return ClosedReopen
+	}
+	return r.Spec.Workspace.Detection.Closed
+}
+
+// StoreIssue 返回 issue 类型的存储保存数据的 issue number，未配置时为 detection.prIssue
+func (r Repository) StoreIssue() int {
+	if r.Spec.Store.Issue != 0 {
+		return r.Spec.Store.Issue
+	}
+	return r.Spec.Workspace.Detection.PRIssue
+}
+
+// Location 返回定时任务使用的时区
+func (r Repository) Location() (*time.Location, error) {
+	if r.Spec.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(r.Spec.Timezone)
+}
+
+// JitterDuration 返回定时任务的随机延迟上限
+func (r Repository) JitterDuration() (time.Duration, error) {
+	if r.Spec.Jitter == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(r.Spec.Jitter)
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRepository_DetectionSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		at       string
+		schedule string
+		want     string
+		wantErr  bool
+	}{
+		{name: "default", want: DefaultSchedule},
+		{name: "legacy at", at: "08:30", want: "30 8 * * *"},
+		{name: "schedule first", at: "08:30", schedule: "@daily", want: "@daily"},
+		{name: "bad at", at: "8h30", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Repository{}
+			r.Spec.Workspace.Detection.At = tt.at
+			r.Spec.Workspace.Detection.Schedule = tt.schedule
+			got, err := r.DetectionSchedule()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DetectionSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DetectionSchedule() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip("no tzdata")
+	}
+	s, err := ParseSchedule("30 8 * * 1-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2020-06-05 是周五
+	from := time.Date(2020, 6, 5, 9, 0, 0, 0, loc)
+	want := time.Date(2020, 6, 8, 8, 30, 0, 0, loc)
+	if got := s.Next(from); !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+
+	if _, err := ParseSchedule("0 0 * *"); err == nil {
+		t.Errorf("ParseSchedule() want error for 4 fields")
+	}
+}
+
+func TestRepository_DetectionBranches(t *testing.T) {
+	r := Repository{}
+	r.Spec.Source.Branch = "main"
+	r.Spec.Workspace.Detection.Branches = []string{"release-1.0", "main", "", "release-1.0", "release-1.1"}
+	got := r.DetectionBranches()
+	want := []string{"main", "release-1.0", "release-1.1"}
+	if len(got) != len(want) {
+		t.Fatalf("DetectionBranches() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("DetectionBranches() = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 上游改动被忽略的原因
+const (
+	IgnoreMessage     = "message"
+	IgnoreLines       = "lines"
+	IgnoreWhitespace  = "whitespace"
+	IgnoreFrontMatter = "front matter"
+	// issue 已关闭，且 detection.closed 为 ignore
+	IgnoreClosed = "closed"
+)
+
+// Significance 判断上游的改动是否需要提醒译者
+// 被忽略的改动仍会更新 issue 的文件列表，但不会添加 detection.addLabel，也不会 comment
+// 被忽略的改动（sha、文件及原因）记录在 issue body 末尾的 Ignored 中
+type Significance struct {
+	// 增删的行数之和小于该值时忽略，0 表示不检查
+	MinLines int `yaml:"minLines"`
+	// 是否忽略只改动了空白字符（空格、缩进、换行）的改动
+	Whitespace bool `yaml:"whitespace"`
+	// 只改动了 front matter 中的这些 key 时忽略，如 date、weight
+	FrontMatter []string `yaml:"frontMatter"`
+	// commit message（pr 方式检测时为 pr 的标题）匹配任意一个正则时忽略，如 "(?i)typo"
+	Messages []string `yaml:"messages"`
+}
+
+// Validate 检查 Messages 中的正则
+func (s Significance) Validate() error {
+	for _, v := range s.Messages {
+		if _, err := regexp.Compile(v); err != nil {
+			return fmt.Errorf("bad detection.ignore.messages %q: %s", v, err.Error())
+		}
+	}
+	return nil
+}
+
+// Ignore 判断一次文件改动是否可以忽略，返回忽略的原因，不可忽略时返回空字符串
+// patch 为 GitHub 返回的 unified diff，为空时（如二进制文件、改动过大）只按 message 和行数判断
+func (s Significance) Ignore(message, patch string, additions, deletions int) string {
+	for _, v := range s.Messages {
+		if re, err := regexp.Compile(v); err == nil && re.MatchString(message) {
+			return IgnoreMessage
+		}
+	}
+	if s.MinLines > 0 && additions+deletions < s.MinLines {
+		return IgnoreLines
+	}
+	if patch == "" {
+		return ""
+	}
+	if s.Whitespace && whitespaceOnly(patch) {
+		return IgnoreWhitespace
+	}
+	if len(s.FrontMatter) > 0 && frontMatterOnly(patch, s.FrontMatter) {
+		return IgnoreFrontMatter
+	}
+	return ""
+}
+
+// 去除空白字符后，删除的内容与新增的内容相同
+func whitespaceOnly(patch string) bool {
+	removed, added := strings.Builder{}, strings.Builder{}
+	for _, line := range strings.Split(patch, "\n") {
+		if line == "" {
+			continue
+		}
+		switch line[0] {
+		case '-':
+			removed.WriteString(strings.Join(strings.Fields(line[1:]), ""))
+		case '+':
+			added.WriteString(strings.Join(strings.Fields(line[1:]), ""))
+		}
+	}
+	return removed.String() == added.String()
+}
+
+// front matter 的解析状态
+const (
+	frontBefore  = iota // 文件开头，还未遇到 front matter 的起始 ---
+	frontInside         // front matter 中
+	frontAfter          // front matter 之后，或者文件没有 front matter
+	frontUnknown        // hunk 不是从文件开头开始，还不能确定是否在 front matter 中
+)
+
+// 改动的行都在 front matter 中，且都是 keys 中的 key（或空行）
+// hunk 不从文件开头开始时，改动之后需要有 front matter 结束的 ---，才认为改动在 front matter 中
+func frontMatterOnly(patch string, keys []string) bool {
+	allowed := make(map[string]bool)
+	for _, v := range keys {
+		allowed[v] = true
+	}
+
+	state, changed, pending := frontUnknown, false, false
+	for _, line := range strings.Split(patch, "\n") {
+		if strings.HasPrefix(line, "@@") {
+			if pending {
+				return false
+			}
+			// 之前的 hunk 已经过了 front matter 时，之后的 hunk 也不在 front matter 中
+			if hunkStart(line) <= 1 {
+				state = frontBefore
+			} else if state != frontAfter {
+				state = frontUnknown
+			}
+			continue
+		}
+		if line == "" || line[0] == '\\' {
+			continue
+		}
+		content := line[1:]
+		if strings.TrimSpace(content) == "---" {
+			if line[0] != ' ' {
+				return false
+			}
+			switch state {
+			case frontBefore:
+				state = frontInside
+			case frontInside, frontUnknown:
+				state, pending = frontAfter, false
+			}
+			continue
+		}
+		if state == frontBefore && strings.TrimSpace(content) != "" {
+			state = frontAfter
+		}
+		if line[0] == ' ' {
+			continue
+		}
+		if state == frontAfter || state == frontBefore {
+			return false
+		}
+		if strings.TrimSpace(content) != "" && !allowed[frontMatterKey(content)] {
+			return false
+		}
+		changed = true
+		if state == frontUnknown {
+			pending = true
+		}
+	}
+	return changed && !pending
+}
+
+// hunk 在原文件中的起始行，如 "@@ -12,7 +12,7 @@" 返回 12
+func hunkStart(header string) int {
+	fields := strings.Fields(header)
+	if len(fields) < 2 {
+		return 0
+	}
+	start := strings.SplitN(strings.TrimPrefix(fields[1], "-"), ",", 2)[0]
+	n, _ := strconv.Atoi(start)
+	return n
+}
+
+// front matter 中顶层的 key，如 "title: Hello" 返回 title，缩进的行返回空字符串
+func frontMatterKey(line string) string {
+	i := strings.Index(line, ":")
+	if i <= 0 || strings.TrimSpace(line[:i]) != line[:i] {
+		return ""
+	}
+	return line[:i]
+}
@@ -0,0 +1,38 @@
+package config
+
+import "testing"
+
+func TestSignificance_Ignore(t *testing.T) {
+	s := Significance{
+		MinLines:    2,
+		Whitespace:  true,
+		FrontMatter: []string{"date", "weight"},
+		Messages:    []string{"(?i)typo"},
+	}
+	tests := []struct {
+		name      string
+		message   string
+		patch     string
+		additions int
+		deletions int
+		want      string
+	}{
+		{name: "message", message: "Fix typo", patch: "@@ -1,1 +1,1 @@\n-a\n+b", additions: 10, deletions: 10, want: IgnoreMessage},
+		{name: "lines", message: "update", patch: "@@ -1,1 +1,1 @@\n+b", additions: 1, want: IgnoreLines},
+		{name: "whitespace", patch: "@@ -3,3 +3,3 @@\n ctx\n-a  b\n+a b\n-\tc\n+c", additions: 2, deletions: 2, want: IgnoreWhitespace},
+		{name: "content", patch: "@@ -3,3 +3,3 @@\n ctx\n-a b\n+a c", additions: 1, deletions: 1, want: ""},
+		{name: "front matter from start", patch: "@@ -1,5 +1,5 @@\n ---\n title: A\n-date: 2020-01-01\n+date: 2020-02-01\n ---", additions: 1, deletions: 1, want: IgnoreFrontMatter},
+		{name: "front matter closed in hunk", patch: "@@ -6,5 +6,5 @@\n tags: [a]\n-weight: 10\n+weight: 20\n ---\n body", additions: 1, deletions: 1, want: IgnoreFrontMatter},
+		{name: "front matter unknown key", patch: "@@ -1,4 +1,4 @@\n ---\n-title: A\n+title: B\n ---", additions: 1, deletions: 1, want: ""},
+		{name: "front matter not closed", patch: "@@ -6,3 +6,3 @@\n tags: [a]\n-weight: 10\n+weight: 20", additions: 1, deletions: 1, want: ""},
+		{name: "after front matter", patch: "@@ -1,3 +1,3 @@\n ---\n ---\n-date: 1\n+date: 2", additions: 1, deletions: 1, want: ""},
+		{name: "empty patch", additions: 5, deletions: 5, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Ignore(tt.message, tt.patch, tt.additions, tt.deletions); got != tt.want {
+				t.Errorf("Ignore() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateFuncs feedback 模板中可以使用的函数，loc 为 now 使用的时区
+func TemplateFuncs(loc *time.Location) template.FuncMap {
+	return template.FuncMap{
+		// {{.Issue.Labels | join ", "}}
+		"join": func(sep string, list []string) string {
+			return strings.Join(list, sep)
+		},
+		// {{mention .Assignees}} => @a, @b
+		"mention": func(list []string) string {
+			users := make([]string, len(list))
+			for k, v := range list {
+				users[k] = "@" + v
+			}
+			return strings.Join(users, ", ")
+		},
+		// {{if has .Issue.Labels "status/stale"}}
+		"has": func(list []string, item string) bool {
+			for _, v := range list {
+				if v == item {
+					return true
+				}
+			}
+			return false
+		},
+		// {{.ResetDate | default "unknown"}}
+		"default": func(def, value string) string {
+			if value == "" {
+				return def
+			}
+			return value
+		},
+		"upper":     strings.ToUpper,
+		"lower":     strings.ToLower,
+		"trim":      strings.TrimSpace,
+		"hasPrefix": strings.HasPrefix,
+		// {{now.Format "2006-01-02"}}，时区为配置的时区
+		"now": func() time.Time {
+			return time.Now().In(loc)
+		},
+	}
+}
+
+// Templates 解析 spec.templates 引用的模板文件，feedback 中可以通过 {{template "<文件名>" .}} 引用
+// 解析失败的文件会被跳过，并返回对应的错误
+func (r Repository) Templates(loc *time.Location) (*template.Template, []error) {
+	base := template.New("").Funcs(TemplateFuncs(loc))
+	errs := make([]error, 0)
+	for _, pattern := range r.Spec.Templates {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("bad template pattern %q: %v", pattern, err))
+			continue
+		}
+		for _, file := range files {
+			t, err := base.ParseFiles(file)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("parse template %s: %v", file, err))
+				continue
+			}
+			base = t
+		}
+	}
+	return base, errs
+}
+
+// 检查 feedback 模板的语法，base 为 spec.templates 中的模板
+func checkTemplate(base *template.Template, text Text) error {
+	langs := make([]string, 0, len(text))
+	for lang := range text {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		t, err := base.Clone()
+		if err != nil {
+			return err
+		}
+		if _, err := t.New("feedback").Parse(text[lang]); err != nil {
+			if lang != "" {
+				return fmt.Errorf("language %q: %v", lang, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// 支持的权限，与 tools.Verify.Permission 保持一致
+var Permissions = []string{"@anyone", "@assigner", "@maintainer", "@member"}
+
+// 检查解析后的配置
+// 包括必填字段、重复的指令、未知的权限、错误的执行时间、未在 Workspace.Labels 中声明的 label 等
+func validate(conf *Config, docs []document) []Problem {
+	problems := make([]Problem, 0)
+	report := func(d document, text string, warning bool, format string, a ...interface{}) {
+		problems = append(problems, Problem{
+			File:    d.file,
+			Line:    d.lineOf(text),
+			Message: fmt.Sprintf(format, a...),
+			Warning: warning,
+		})
+	}
+
+	permissions := make(map[string]bool)
+	for _, v := range Permissions {
+		permissions[v] = true
+	}
+	// 已声明的 label，为空时不检查
+	declared := make(map[string]bool)
+	for _, v := range conf.Repository.Spec.Workspace.Labels {
+		declared[v.Name] = true
+	}
+	checkLabels := func(d document, labels []string) {
+		if len(declared) == 0 {
+			return
+		}
+		for _, v := range labels {
+			if !declared[v] {
+				report(d, v, true, "label %q is not declared in spec.workspace.labels", v)
+			}
+		}
+	}
+
+	// 检查 feedback 模板的语法，模板中可以引用 spec.templates 中的模板
+	templates, templateErrs := conf.Repository.Templates(time.Local)
+	checkFeedback := func(d document, field string, text Text) {
+		if err := checkTemplate(templates, text); err != nil {
+			report(d, field+":", false, "bad %s: %s", field, err.Error())
+		}
+	}
+
+	hasRepository := false
+	instructs := make(map[string]string)
+	for _, d := range docs {
+		switch v := d.value.(type) {
+		case Repository:
+			hasRepository = true
+			if v.Spec.Workspace.Owner == "" {
+				report(d, "workspace:", false, "missing required field spec.workspace.owner")
+			}
+			if spec, err := v.DetectionSchedule(); err != nil {
+				report(d, "at:", false, "%s", err.Error())
+			} else if _, err := ParseSchedule(spec); err != nil {
+				report(d, "schedule:", false, "bad detection.schedule %q: %s", spec, err.Error())
+			}
+			if mode := v.DetectionMode(); mode != DetectionPR && mode != DetectionCommit {
+				report(d, "mode:", false, "unknown detection.mode %q, want %s or %s", mode, DetectionPR, DetectionCommit)
+			}
+			if checkpoint := v.DetectionCheckpoint(); checkpoint != CheckpointStore && checkpoint != CheckpointIssue {
+				report(d, "checkpoint:", false, "unknown detection.checkpoint %q, want %s or %s", checkpoint, CheckpointStore, CheckpointIssue)
+			}
+			if checkpoint := v.DetectionCheckpoint(); checkpoint == CheckpointIssue && v.Spec.Workspace.Detection.Enable && v.Spec.Workspace.Detection.PRIssue == 0 {
+				report(d, "checkpoint:", false, "detection.checkpoint %q requires detection.prIssue", checkpoint)
+			}
+			if closed := v.DetectionClosed(); closed != ClosedReopen && closed != ClosedUpdate && closed != ClosedIgnore {
+				report(d, "closed:", false, "unknown detection.closed %q, want %s, %s or %s", closed, ClosedReopen, ClosedUpdate, ClosedIgnore)
+			}
+			if err := v.Spec.Workspace.Detection.Ignore.Validate(); err != nil {
+				report(d, "messages:", false, "%s", err.Error())
+			}
+			if backend := v.Spec.Store.Backend; backend != "" && backend != "bolt" && backend != "file" && backend != "issue" {
+				report(d, "backend:", false, "unknown store.backend %q, want bolt, file or issue", backend)
+			} else if backend == "issue" && v.StoreIssue() == 0 {
+				report(d, "backend:", false, "store.backend %q requires store.issue or detection.prIssue", backend)
+			}
+			if _, err := v.Location(); err != nil {
+				report(d, "timezone:", false, "bad timezone: %s", err.Error())
+			}
+			if _, err := v.JitterDuration(); err != nil {
+				report(d, "jitter:", false, "bad jitter: %s", err.Error())
+			}
+			for _, err := range templateErrs {
+				report(d, "templates:", false, "%s", err.Error())
+			}
+		case IssueCreate:
+			if m := v.Spec.Match; m != "" && m != MatchFirst && m != MatchSpecific {
+				report(d, "match:", false, "unknown match %q, want %s or %s", m, MatchFirst, MatchSpecific)
+			}
+			for _, t := range v.Spec.Types {
+				if t.Ext == "" {
+					report(d, "types:", false, "missing required field spec.types.ext")
+				}
+				if t.GroupBy != "" && t.GroupBy != "directory" && t.GroupBy != "file" {
+					report(d, t.GroupBy, false, "unknown groupBy %q of type %q, want directory or file", t.GroupBy, t.Ext)
+				}
+			}
+			conflicts := conf.Repository.LabelConflicts(v.Spec.Labels)
+			names := make([]string, 0, len(conflicts))
+			for name := range conflicts {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				report(d, "labels:", true, "labels %v belong to the same label group %q", conflicts[name], name)
+			}
+			for _, include := range v.Spec.Includes {
+				if err := include.Validate(); err != nil {
+					report(d, include.Path, false, "%s", err.Error())
+				}
+			}
+		case IssueComment:
+			if v.Spec.Rules == nil || v.Spec.Rules.Instruct == "" {
+				report(d, "spec:", false, "missing required field spec.rules.instruct")
+				continue
+			}
+			rules := v.Spec.Rules
+			if name, ok := instructs[rules.Instruct]; ok {
+				report(d, "instruct:", false, "instruct %q is already defined by %s", rules.Instruct, name)
+			}
+			instructs[rules.Instruct] = v.Metadata.Name
+			for _, p := range rules.Permissions {
+				if !permissions[p] {
+					report(d, p, false, "unknown permission %q, want one of %v", p, Permissions)
+				}
+			}
+			checkLabels(d, rules.Labels)
+			checkFeedback(d, "permissionFeedback", rules.PermissionFeedback)
+			checkFeedback(d, "labelFeedback", rules.LabelFeedback)
+			checkFeedback(d, "assignerFeedback", rules.AssignerFeedback)
+			if v.Spec.Action == nil {
+				report(d, "spec:", false, "missing required field spec.action")
+				continue
+			}
+			checkLabels(d, v.Spec.Action.AddLabels)
+			checkLabels(d, v.Spec.Action.RemoveLabels)
+			checkFeedback(d, "labelLimitFeedback", v.Spec.Action.LabelLimitFeedback)
+			checkFeedback(d, "successFeedback", v.Spec.Action.SuccessFeedback)
+			checkFeedback(d, "failFeedback", v.Spec.Action.FailFeedback)
+			for _, message := range conf.Repository.RequiredConflicts(rules.Labels, v.Spec.Action.AddLabels, v.Spec.Action.RemoveLabels) {
+				report(d, "addLabels:", true, "%s", message)
+			}
+			if delay := v.Spec.Action.Delay; delay != nil {
+				if !hasJob(conf, delay.Job) {
+					report(d, "job:", false, "unknown job %q", delay.Job)
+				}
+				checkFeedback(d, "limitFeedback", delay.LimitFeedback)
+			}
+		case Job:
+			if v.Spec.Schedule != "" {
+				if _, err := ParseSchedule(v.Spec.Schedule); err != nil {
+					report(d, "schedule:", false, "bad schedule %q: %s", v.Spec.Schedule, err.Error())
+				}
+			}
+			checkLabels(d, v.Spec.Labels)
+			checkLabels(d, v.Spec.AddLabels)
+			checkLabels(d, v.Spec.RemoveLabels)
+			for _, message := range conf.Repository.RequiredConflicts(v.Spec.Labels, v.Spec.AddLabels, v.Spec.RemoveLabels) {
+				report(d, "addLabels:", true, "%s", message)
+			}
+			checkFeedback(d, "feedback", v.Spec.Feedback)
+			checkFeedback(d, "feedback", v.Spec.Remind.Feedback)
+		case Workflow:
+			for _, message := range v.Validate() {
+				report(d, "transitions:", true, "%s", message)
+			}
+			for _, c := range v.Compile() {
+				if name, ok := instructs[c.Spec.Rules.Instruct]; ok {
+					report(d, c.Spec.Rules.Instruct, false, "instruct %q is already defined by %s", c.Spec.Rules.Instruct, name)
+				}
+				instructs[c.Spec.Rules.Instruct] = c.Metadata.Name
+				checkFeedback(d, "permissionFeedback", c.Spec.Rules.PermissionFeedback)
+				checkFeedback(d, "labelFeedback", c.Spec.Rules.LabelFeedback)
+				checkFeedback(d, "labelLimitFeedback", c.Spec.Action.LabelLimitFeedback)
+				checkFeedback(d, "successFeedback", c.Spec.Action.SuccessFeedback)
+				checkFeedback(d, "failFeedback", c.Spec.Action.FailFeedback)
+			}
+		}
+	}
+	if !hasRepository {
+		problems = append(problems, Problem{Message: "missing Repository config"})
+	}
+
+	for _, message := range conf.removedLabels() {
+		problems = append(problems, Problem{Message: message, Warning: true})
+	}
+	return problems
+}
+
+func hasJob(conf *Config, name string) bool {
+	for _, v := range conf.Jobs {
+		if v.Metadata.Name == name {
+			return true
+		}
+	}
+	return false
+}
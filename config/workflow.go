@@ -0,0 +1,330 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Workflow 以状态机的方式声明工作流程
+// 每个状态对应一个 label，每个转换对应一条指令
+// 加载配置时，会被编译为 IssueComment
+type Workflow struct {
+	Base `yaml:",inline"`
+	Spec struct {
+		// 状态 label 的前缀，如 status/，用于检查 issue 是否会同时处于多个状态
+		Prefix string `yaml:"prefix"`
+		// 初始状态，即 issue 创建时所处的状态
+		Initial     string       `yaml:"initial"`
+		States      []State      `yaml:"states"`
+		Transitions []Transition `yaml:"transitions"`
+	} `yaml:"spec"`
+}
+
+// 状态
+type State struct {
+	Name string `yaml:"name"`
+	// 状态对应的 label，默认为 Prefix + Name
+	Label string `yaml:"label"`
+}
+
+// 状态转换，即一条指令
+type Transition struct {
+	Instruct string `yaml:"instruct"`
+	// 来源状态，为空表示任意状态
+	From               string   `yaml:"from"`
+	To                 string   `yaml:"to"`
+	Permissions        []string `yaml:"permissions"`
+	PermissionFeedback Text     `yaml:"permissionFeedback"`
+	LabelFeedback      Text     `yaml:"labelFeedback"`
+	// 其它动作，与 IssueComment 的 action 相同
+	// 状态 label 的添加和移除会自动生成，不需要在这里配置
+	Action Action `yaml:"action"`
+}
+
+// 状态对应的 label
+func (w Workflow) StateLabel(name string) string {
+	for _, s := range w.Spec.States {
+		if s.Name == name {
+			if s.Label != "" {
+				return s.Label
+			}
+			return w.Spec.Prefix + s.Name
+		}
+	}
+	return ""
+}
+
+// 所有状态对应的 label
+func (w Workflow) stateLabels() []string {
+	labels := make([]string, 0, len(w.Spec.States))
+	for _, s := range w.Spec.States {
+		labels = append(labels, w.StateLabel(s.Name))
+	}
+	return labels
+}
+
+// 转换的来源状态，From 为空时为除目标状态外的所有状态
+func (w Workflow) sources(t Transition) []string {
+	if t.From != "" {
+		return []string{t.From}
+	}
+	sources := make([]string, 0)
+	for _, s := range w.Spec.States {
+		if s.Name != t.To {
+			sources = append(sources, s.Name)
+		}
+	}
+	return sources
+}
+
+// Compile 将状态转换编译为指令
+func (w Workflow) Compile() []IssueComment {
+	comments := make([]IssueComment, 0, len(w.Spec.Transitions))
+	for _, t := range w.Spec.Transitions {
+		action := t.Action
+		to := w.StateLabel(t.To)
+		action.RemoveLabels = make([]string, 0)
+		if t.From != "" {
+			action.RemoveLabels = append(action.RemoveLabels, w.StateLabel(t.From))
+		} else {
+			// 来自任意状态时，移除所有其它状态
+			for _, label := range w.stateLabels() {
+				if label != to {
+					action.RemoveLabels = append(action.RemoveLabels, label)
+				}
+			}
+		}
+		action.RemoveLabels = append(action.RemoveLabels, t.Action.RemoveLabels...)
+		action.AddLabels = append([]string{to}, t.Action.AddLabels...)
+
+		rule := Rule{
+			Instruct:           t.Instruct,
+			Permissions:        t.Permissions,
+			PermissionFeedback: t.PermissionFeedback,
+			LabelFeedback:      t.LabelFeedback,
+		}
+		if t.From != "" {
+			rule.Labels = []string{w.StateLabel(t.From)}
+		}
+
+		c := IssueComment{}
+		c.ApiVersion = w.ApiVersion
+		c.Kind = "IssueComment"
+		c.Metadata.Name = fmt.Sprintf("%s-%s", w.Metadata.Name, t.Instruct)
+		c.Spec.Rules = &rule
+		c.Spec.Action = &action
+		comments = append(comments, c)
+	}
+	return comments
+}
+
+// Validate 检查状态机中的错误，返回问题列表，为空表示没有问题
+// 包括：未知的状态、重复的状态或指令、无法到达的状态、转换后处于多个状态
+func (w Workflow) Validate() []string {
+	problems := make([]string, 0)
+	report := func(format string, a ...interface{}) {
+		problems = append(problems, fmt.Sprintf("workflow %s: ", w.Metadata.Name)+fmt.Sprintf(format, a...))
+	}
+
+	states := make(map[string]bool)
+	for _, s := range w.Spec.States {
+		if states[s.Name] {
+			report("duplicate state %q", s.Name)
+		}
+		states[s.Name] = true
+	}
+	if !states[w.Spec.Initial] {
+		report("unknown initial state %q", w.Spec.Initial)
+	}
+
+	instructs := make(map[string]bool)
+	for _, t := range w.Spec.Transitions {
+		if instructs[t.Instruct] {
+			report("duplicate instruct %q", t.Instruct)
+		}
+		instructs[t.Instruct] = true
+		if t.From != "" && !states[t.From] {
+			report("instruct %q: unknown state %q", t.Instruct, t.From)
+		}
+		if !states[t.To] {
+			report("instruct %q: unknown state %q", t.Instruct, t.To)
+		}
+	}
+
+	// 从初始状态出发，检查无法到达的状态
+	reached := map[string]bool{w.Spec.Initial: true}
+	for changed := true; changed; {
+		changed = false
+		for _, t := range w.Spec.Transitions {
+			if reached[t.To] {
+				continue
+			}
+			for _, from := range w.sources(t) {
+				if reached[from] {
+					reached[t.To] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	for _, s := range w.Spec.States {
+		if !reached[s.Name] {
+			report("state %q is unreachable from %q", s.Name, w.Spec.Initial)
+		}
+	}
+
+	// 模拟每个转换，检查转换后是否处于多个状态
+	compiled := w.Compile()
+	for k, t := range w.Spec.Transitions {
+		action := compiled[k].Spec.Action
+		for _, from := range w.sources(t) {
+			labels := remove([]string{w.StateLabel(from)}, action.RemoveLabels...)
+			labels = append(labels, action.AddLabels...)
+			if current := w.statesOf(labels); len(current) > 1 {
+				report("instruct %q from %q leaves the issue in states %s", t.Instruct, from, strings.Join(current, ", "))
+			}
+		}
+	}
+	return problems
+}
+
+// 根据 label 判断所处的状态，已排序并去重
+func (w Workflow) statesOf(labels []string) []string {
+	exist := make(map[string]bool)
+	for _, label := range labels {
+		if w.Spec.Prefix != "" && strings.HasPrefix(label, w.Spec.Prefix) {
+			exist[label] = true
+			continue
+		}
+		for _, l := range w.stateLabels() {
+			if l == label {
+				exist[label] = true
+			}
+		}
+	}
+	current := make([]string, 0, len(exist))
+	for label := range exist {
+		current = append(current, label)
+	}
+	sort.Strings(current)
+	return current
+}
+
+func remove(source []string, values ...string) []string {
+	result := make([]string, 0, len(source))
+	for _, v := range source {
+		found := false
+		for _, r := range values {
+			if v == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// Mermaid 输出 Mermaid 格式的状态图
+func (w Workflow) Mermaid() string {
+	ids := make(map[string]string)
+	b := strings.Builder{}
+	b.WriteString("stateDiagram-v2\n")
+	for k, s := range w.Spec.States {
+		ids[s.Name] = fmt.Sprintf("s%d", k)
+		b.WriteString(fmt.Sprintf("    state \"%s\" as s%d\n", s.Name, k))
+	}
+	if id, ok := ids[w.Spec.Initial]; ok {
+		b.WriteString(fmt.Sprintf("    [*] --> %s\n", id))
+	}
+	for _, t := range w.Spec.Transitions {
+		for _, from := range w.sources(t) {
+			if ids[from] == "" || ids[t.To] == "" {
+				continue
+			}
+			b.WriteString(fmt.Sprintf("    %s --> %s: /%s\n", ids[from], ids[t.To], t.Instruct))
+		}
+	}
+	return b.String()
+}
+
+// DOT 输出 Graphviz DOT 格式的状态图
+func (w Workflow) DOT() string {
+	b := strings.Builder{}
+	b.WriteString(fmt.Sprintf("digraph %q {\n", w.Metadata.Name))
+	b.WriteString("    \"[*]\" [shape=point];\n")
+	for _, s := range w.Spec.States {
+		b.WriteString(fmt.Sprintf("    %q [label=%q];\n", s.Name, fmt.Sprintf("%s\n%s", s.Name, w.StateLabel(s.Name))))
+	}
+	if w.Spec.Initial != "" {
+		b.WriteString(fmt.Sprintf("    \"[*]\" -> %q;\n", w.Spec.Initial))
+	}
+	for _, t := range w.Spec.Transitions {
+		for _, from := range w.sources(t) {
+			b.WriteString(fmt.Sprintf("    %q -> %q [label=%q];\n", from, t.To, "/"+t.Instruct))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// CompileWorkflows 将所有 Workflow 编译为指令，追加至 IssueComments
+func (c *Config) CompileWorkflows() {
+	for _, w := range c.Workflows {
+		c.IssueComments = append(c.IssueComments, w.Compile()...)
+	}
+}
+
+// 被移除、但从未被添加的 label
+// 包括指令、Job 以及 Detection 中添加的 label
+func (c Config) removedLabels() []string {
+	added := make(map[string]bool)
+	mark := func(labels []string) {
+		for _, v := range labels {
+			added[v] = true
+		}
+	}
+	mark(c.IssueCreate.Spec.Labels)
+	var markIncludes func(includes []Include)
+	markIncludes = func(includes []Include) {
+		for _, include := range includes {
+			mark(include.Labels)
+			markIncludes(include.Includes)
+		}
+	}
+	markIncludes(c.IssueCreate.Spec.Includes)
+	mark(c.Repository.Spec.Workspace.Detection.AddLabel)
+	for _, w := range c.Workflows {
+		mark([]string{w.StateLabel(w.Spec.Initial)})
+	}
+	for _, v := range c.IssueComments {
+		if v.Spec.Action != nil {
+			mark(v.Spec.Action.AddLabels)
+		}
+	}
+	for _, v := range c.Jobs {
+		mark(v.Spec.AddLabels)
+	}
+
+	problems := make([]string, 0)
+	check := func(owner string, labels []string) {
+		for _, v := range labels {
+			if !added[v] {
+				problems = append(problems, fmt.Sprintf("%s: label %q is removed but never added", owner, v))
+			}
+		}
+	}
+	for _, v := range c.IssueComments {
+		if v.Spec.Rules != nil && v.Spec.Action != nil {
+			check(fmt.Sprintf("instruct %s", v.Spec.Rules.Instruct), v.Spec.Action.RemoveLabels)
+		}
+	}
+	for _, v := range c.Jobs {
+		check(fmt.Sprintf("job %s", v.Metadata.Name), v.Spec.RemoveLabels)
+	}
+	return problems
+}
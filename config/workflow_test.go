@@ -0,0 +1,80 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testWorkflow() Workflow {
+	w := Workflow{}
+	w.Metadata.Name = "test"
+	w.Spec.Prefix = "status/"
+	w.Spec.Initial = "pending"
+	w.Spec.States = []State{{Name: "pending"}, {Name: "doing"}, {Name: "done"}}
+	w.Spec.Transitions = []Transition{
+		{Instruct: "accept", From: "pending", To: "doing"},
+		{Instruct: "finish", From: "doing", To: "done"},
+	}
+	return w
+}
+
+func TestWorkflow_Compile(t *testing.T) {
+	w := testWorkflow()
+	w.Spec.Transitions = append(w.Spec.Transitions, Transition{Instruct: "reset", To: "pending"})
+	got := w.Compile()
+	if len(got) != 3 {
+		t.Fatalf("Compile() got %d instructs, want 3", len(got))
+	}
+	if !reflect.DeepEqual(got[0].Spec.Rules.Labels, []string{"status/pending"}) ||
+		!reflect.DeepEqual(got[0].Spec.Action.RemoveLabels, []string{"status/pending"}) ||
+		!reflect.DeepEqual(got[0].Spec.Action.AddLabels, []string{"status/doing"}) {
+		t.Errorf("Compile() accept = %+v, %+v", got[0].Spec.Rules, got[0].Spec.Action)
+	}
+	if got[2].Spec.Rules.Labels != nil ||
+		!reflect.DeepEqual(got[2].Spec.Action.RemoveLabels, []string{"status/doing", "status/done"}) {
+		t.Errorf("Compile() reset = %+v, %+v", got[2].Spec.Rules, got[2].Spec.Action)
+	}
+}
+
+func TestWorkflow_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(w *Workflow)
+		want   []string
+	}{
+		{name: "valid", modify: func(w *Workflow) {}, want: []string{}},
+		{
+			name: "unreachable",
+			modify: func(w *Workflow) {
+				w.Spec.States = append(w.Spec.States, State{Name: "stale"})
+			},
+			want: []string{`workflow test: state "stale" is unreachable from "pending"`},
+		},
+		{
+			name: "unknown state",
+			modify: func(w *Workflow) {
+				w.Spec.Transitions[1].To = "closed"
+			},
+			want: []string{
+				`workflow test: instruct "finish": unknown state "closed"`,
+				`workflow test: state "done" is unreachable from "pending"`,
+			},
+		},
+		{
+			name: "multiple states",
+			modify: func(w *Workflow) {
+				w.Spec.Transitions[0].Action.AddLabels = []string{"status/done"}
+			},
+			want: []string{`workflow test: instruct "accept" from "pending" leaves the issue in states status/doing, status/done`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testWorkflow()
+			tt.modify(&w)
+			if got := w.Validate(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
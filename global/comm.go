@@ -4,11 +4,14 @@ package global
 
 import (
 	"context"
+	"errors"
 	c "github.com/google/go-github/v30/github"
 	"go.uber.org/zap"
 	"golang.org/x/oauth2"
 	"issue-man/config"
+	"issue-man/store"
 	"sync"
+	"time"
 )
 
 // 各种全局对象
@@ -25,6 +28,12 @@ var (
 	// 日志对象
 	Sugar *zap.SugaredLogger
 
+	// 运行时状态的存储
+	Store store.Store
+
+	// 定时任务及日期显示使用的时区
+	Location = time.Local
+
 	// 任务仓库 maintainer 列表
 	// 判断某个用户是否为 maintainer，直接使用变量，根据返回值即可判断
 	// 例如：result:=global.Maintainers["gorda"]，然后判断返回值即可。
@@ -84,6 +93,13 @@ func Init(token string, conf *config.Config) {
 	}
 	Sugar.Debugw("load jobs", "done", Jobs)
 
+	// 初始化时区
+	loc, err := Conf.Repository.Location()
+	if err != nil {
+		panic(err.Error())
+	}
+	Location = loc
+
 	// 初始化 GitHub Client
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
@@ -96,3 +112,46 @@ func Init(token string, conf *config.Config) {
 	// 获取 Team 成员列表
 	LoadMaintainers()
 }
+
+// OpenStore 打开运行时状态的存储
+// readOnly 为 true 时，读取存储的快照，写入的数据不会保存，存储被 start 启动的服务占用时，使用空的存储
+func OpenStore(readOnly bool) error {
+	backend, path := Conf.Repository.Spec.Store.Backend, Conf.Repository.Spec.Store.Path
+	// 保存在 issue body 中的存储没有文件锁，只读时同样读取至内存
+	if backend == store.BackendIssue {
+		s, err := store.OpenIssue(storeIssue{number: Conf.Repository.StoreIssue()})
+		if err != nil {
+			return err
+		}
+		if readOnly {
+			snapshot := store.NewMemory()
+			if err := store.Copy(snapshot, s); err != nil {
+				return err
+			}
+			s = snapshot
+		}
+		Store = s
+		return nil
+	}
+	if !readOnly {
+		s, err := store.Open(backend, path)
+		if err != nil {
+			return err
+		}
+		Store = s
+		return nil
+	}
+	s, err := store.OpenSnapshot(backend, path)
+	if errors.Is(err, store.ErrLocked) {
+		Sugar.Warnw("open store",
+			"status", "locked, use an empty store",
+			"err", err.Error(),
+		)
+		s, err = store.NewMemory(), nil
+	}
+	if err != nil {
+		return err
+	}
+	Store = s
+	return nil
+}
@@ -0,0 +1,67 @@
+package global
+
+import (
+	"context"
+	"fmt"
+	c "github.com/google/go-github/v30/github"
+	"net/http"
+)
+
+// 工作仓库中保存存储数据的 issue，实现 store.Issue
+// 放在 global 中，以免 store 依赖 tools
+type storeIssue struct {
+	number int
+}
+
+func (i storeIssue) Body() (string, error) {
+	issue, resp, err := Client.Issues.Get(context.TODO(),
+		Conf.Repository.Spec.Workspace.Owner,
+		Conf.Repository.Spec.Workspace.Repository,
+		i.number,
+	)
+	if err != nil {
+		Sugar.Errorw("load store issue",
+			"call api", "failed",
+			"number", i.number,
+			"err", err.Error(),
+		)
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		Sugar.Errorw("load store issue",
+			"call api", "unexpect status code",
+			"number", i.number,
+			"status", resp.Status,
+			"status code", resp.StatusCode,
+		)
+		return "", fmt.Errorf("load store issue fail. status code:%d", resp.StatusCode)
+	}
+	return issue.GetBody(), nil
+}
+
+func (i storeIssue) EditBody(body string) error {
+	_, resp, err := Client.Issues.Edit(context.TODO(),
+		Conf.Repository.Spec.Workspace.Owner,
+		Conf.Repository.Spec.Workspace.Repository,
+		i.number,
+		&c.IssueRequest{Body: &body},
+	)
+	if err != nil {
+		Sugar.Errorw("save store issue",
+			"call api", "failed",
+			"number", i.number,
+			"err", err.Error(),
+		)
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		Sugar.Errorw("save store issue",
+			"call api", "unexpect status code",
+			"number", i.number,
+			"status", resp.Status,
+			"status code", resp.StatusCode,
+		)
+		return fmt.Errorf("save store issue fail. status code:%d", resp.StatusCode)
+	}
+	return nil
+}
@@ -6,9 +6,11 @@ require (
 	github.com/gin-gonic/gin v1.6.1
 	github.com/google/go-github/v30 v30.1.0
 	github.com/google/uuid v1.1.1
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/spf13/afero v1.1.2
 	github.com/spf13/cobra v0.0.6
 	github.com/spf13/viper v1.4.0
+	go.etcd.io/bbolt v1.3.5
 	go.uber.org/zap v1.10.0
 	golang.org/x/oauth2 v0.0.0-20180821212333-d2e6202438be
 	gopkg.in/go-playground/webhooks.v5 v5.13.0
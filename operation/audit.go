@@ -0,0 +1,175 @@
+package operation
+
+import (
+	"errors"
+	"fmt"
+	gg "github.com/google/go-github/v30/github"
+	"issue-man/comm"
+	"issue-man/global"
+	"issue-man/store"
+	"issue-man/tools"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// issue 在改动之后又被修改过，不能撤销
+var errChanged = errors.New("issue has been changed since")
+
+// 记录指令对 issue 做出的改动，用于撤销
+func record(info comm.Info, updated *gg.Issue) {
+	r := store.Record{
+		ReqID:       info.ReqID,
+		CommentID:   info.CommentID,
+		IssueNumber: info.IssueNumber,
+		Instruct:    info.Instruct,
+		Login:       info.Login,
+		At:          time.Now(),
+		Before:      newIssueState(info.Labels, info.Assignees, info.State),
+		After:       issueState(updated),
+	}
+	if err := store.AppendRecord(global.Store, r); err != nil {
+		global.Sugar.Errorw("save audit record",
+			"req_id", info.ReqID,
+			"record", r,
+			"err", err.Error())
+	}
+}
+
+// 撤销一条改动记录，将 issue 恢复至改动之前的状态
+// 要求 issue 当前的状态与改动之后的状态一致，否则拒绝撤销
+// 撤销本身也会被记录
+func revert(r store.Record, info comm.Info) error {
+	issue, err := tools.Issue.Get(r.IssueNumber)
+	if err != nil {
+		return err
+	}
+	current := issueState(issue)
+	if !reflect.DeepEqual(current, r.After) {
+		global.Sugar.Infow("revert",
+			"req_id", info.ReqID,
+			"record", r,
+			"current", current,
+			"status", "changed")
+		return errChanged
+	}
+
+	edit := tools.Convert.Issue(issue)
+	edit.Labels = tools.Get.Strings(r.Before.Labels)
+	edit.Assignees = tools.Get.Strings(r.Before.Assignees)
+	edit.State = tools.Get.String(r.Before.State)
+	updated, err := tools.Issue.EditByIssueRequest(r.IssueNumber, edit)
+	if err != nil {
+		return err
+	}
+
+	// 标记为已撤销
+	records, err := store.ListRecords(global.Store, r.IssueNumber)
+	if err != nil {
+		return err
+	}
+	for k := range records {
+		if records[k].ReqID == r.ReqID {
+			records[k].Undone = true
+		}
+	}
+	records = append(records, store.Record{
+		ReqID:       info.ReqID,
+		CommentID:   info.CommentID,
+		IssueNumber: r.IssueNumber,
+		Instruct:    fmt.Sprintf("undo %s", r.ReqID),
+		Reverts:     r.ReqID,
+		Login:       info.Login,
+		At:          time.Now(),
+		Before:      current,
+		After:       issueState(updated),
+	})
+	if err := store.PutRecords(global.Store, r.IssueNumber, records); err != nil {
+		return err
+	}
+	global.Sugar.Infow("revert",
+		"req_id", info.ReqID,
+		"record", r,
+		"status", "done")
+	return nil
+}
+
+// 撤销并反馈结果
+func revertAndFeedback(r store.Record, info comm.Info) {
+	key := "undo.done"
+	err := revert(r, info)
+	if err == errChanged {
+		key = "undo.changed"
+	} else if err != nil {
+		return
+	}
+	hc := comm.NewComment(info)
+	tools.Issue.Comment(r.IssueNumber, fmt.Sprintf(global.Conf.Repository.Message(key, hc.Languages()...), r.Instruct, r.ReqID))
+}
+
+// undo 执行撤销指令
+// 参数为 req-id 时撤销指定的改动，否则撤销最近一次改动
+func undo(info comm.Info) {
+	records, err := store.ListRecords(global.Store, info.IssueNumber)
+	if err != nil {
+		global.Sugar.Errorw("undo",
+			"req_id", info.ReqID,
+			"step", "list records",
+			"err", err.Error())
+		return
+	}
+
+	reqID := ""
+	if len(info.Args) > 0 {
+		reqID = info.Args[0]
+	}
+	r, ok := findRecord(records, reqID)
+	if !ok {
+		global.Sugar.Infow("undo",
+			"req_id", info.ReqID,
+			"target", reqID,
+			"status", "not found")
+		hc := comm.NewComment(info)
+		tools.Issue.Comment(info.IssueNumber, global.Conf.Repository.Message("undo.notFound", hc.Languages()...))
+		return
+	}
+	revertAndFeedback(r, info)
+}
+
+// 查找可以撤销的改动记录
+// reqID 为空时，返回最近一次未被撤销、且本身不是撤销操作的记录
+func findRecord(records []store.Record, reqID string) (store.Record, bool) {
+	for i := len(records) - 1; i >= 0; i-- {
+		r := records[i]
+		if r.Undone {
+			continue
+		}
+		if reqID == "" && r.Reverts == "" || reqID != "" && r.ReqID == reqID {
+			return r, true
+		}
+	}
+	return store.Record{}, false
+}
+
+func issueState(issue *gg.Issue) store.IssueState {
+	labels, assignees := make([]string, 0), make([]string, 0)
+	if issue.Labels != nil {
+		labels = *tools.Convert.Label(issue.Labels)
+	}
+	if issue.Assignees != nil {
+		assignees = *tools.Convert.Assignees(issue.Assignees)
+	}
+	return newIssueState(labels, assignees, issue.GetState())
+}
+
+// labels 和 assignees 排序后保存，便于比较
+func newIssueState(labels, assignees []string, state string) store.IssueState {
+	s := store.IssueState{
+		Labels:    *tools.Get.Strings(labels),
+		Assignees: *tools.Get.Strings(assignees),
+		State:     state,
+	}
+	sort.Strings(s.Labels)
+	sort.Strings(s.Assignees)
+	return s
+}
@@ -0,0 +1,34 @@
+package operation
+
+import (
+	"issue-man/store"
+	"testing"
+)
+
+func Test_findRecord(t *testing.T) {
+	records := []store.Record{
+		{ReqID: "a"},
+		{ReqID: "b", Undone: true},
+		{ReqID: "c", Reverts: "b"},
+	}
+	tests := []struct {
+		name   string
+		reqID  string
+		want   string
+		wantOk bool
+	}{
+		{name: "latest", reqID: "", want: "a", wantOk: true},
+		{name: "specific", reqID: "a", want: "a", wantOk: true},
+		{name: "undone", reqID: "b", wantOk: false},
+		{name: "undo record", reqID: "c", want: "c", wantOk: true},
+		{name: "unknown", reqID: "x", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := findRecord(records, tt.reqID)
+			if ok != tt.wantOk || got.ReqID != tt.want {
+				t.Errorf("findRecord() = %v, %v, want %v, %v", got.ReqID, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
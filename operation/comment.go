@@ -0,0 +1,65 @@
+package operation
+
+import (
+	"gopkg.in/go-playground/webhooks.v5/github"
+	"issue-man/comm"
+	"issue-man/global"
+	"issue-man/store"
+	"issue-man/tools"
+)
+
+const (
+	// 评论被编辑时，仅执行新增的指令
+	EditedNew = "new"
+	// 评论被删除时，撤销该评论中的指令做出的改动
+	DeletedUndo = "undo"
+)
+
+// CommentEdited 处理被编辑的评论
+// from 为编辑前的评论内容
+func CommentEdited(payload github.IssueCommentPayload, from string) {
+	if global.Conf.Repository.Spec.Workspace.Comment.Edited != EditedNew {
+		return
+	}
+
+	before := tools.Parse.Instruct(from)
+	added := make(map[string][]string)
+	for instruct, mention := range tools.Parse.Instruct(payload.Comment.Body) {
+		if _, ok := before[instruct]; !ok {
+			added[instruct] = mention
+		}
+	}
+	global.Sugar.Debugw("comment edited",
+		"comment", payload.Comment.ID,
+		"before", before,
+		"added", added)
+	if len(added) == 0 {
+		return
+	}
+	IssueHanding(payload, added)
+}
+
+// CommentDeleted 处理被删除的评论
+// 按从新到旧的顺序，撤销该评论中的指令做出的改动
+func CommentDeleted(payload github.IssueCommentPayload) {
+	if global.Conf.Repository.Spec.Workspace.Comment.Deleted != DeletedUndo {
+		return
+	}
+
+	info := comm.Info{}
+	info.Parse(payload)
+	records, err := store.ListRecords(global.Store, info.IssueNumber)
+	if err != nil {
+		global.Sugar.Errorw("comment deleted",
+			"req_id", info.ReqID,
+			"step", "list records",
+			"err", err.Error())
+		return
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].CommentID != info.CommentID || records[i].Undone {
+			continue
+		}
+		revertAndFeedback(records[i], info)
+	}
+}
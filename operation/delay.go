@@ -1,43 +1,193 @@
 package operation
 
-/*
 import (
+	"errors"
 	"fmt"
+	gg "github.com/google/go-github/v30/github"
+	"issue-man/comm"
+	"issue-man/config"
+	"issue-man/global"
+	"issue-man/store"
+	"issue-man/tools"
+	"sort"
 	"time"
 )
 
-// 获取最终重置日期
-// 根据 event 获取 label 创建时间，计算出原定重置日期
-// 根据最后执行延迟指令的日期和延迟的时长，计算期望重置日期
-// 返回较大的那个日期
-func getResetDate(owner, repository string, issueNumber int, labels []string, in, delay int, instructName string) (resetDate time.Time, err error) {
-	defer func() {
-		fmt.Printf("get reset date: %v\n", resetDate.String())
-	}()
-	createdAt, err := getLabelCreateAt(owner, repository, issueNumber, labels)
+// 默认的提醒阶段，即距离截止时间 8、4、2、1 天时各提醒一次
+var defaultRemindDays = []int{8, 4, 2, 1}
+
+// 获取 issue 在 job 中的截止时间
+// 没有记录，或者 issue 重新进入了该状态（since 发生了变化）时，
+// 根据 since 和 Job.Spec.In 计算新的截止时间，并保存
+func getDeadline(job config.Job, number int, since time.Time) (store.Deadline, error) {
+	d, ok, err := store.GetDeadline(global.Store, job.Metadata.Name, number)
+	if err != nil {
+		global.Sugar.Errorw("get deadline",
+			"job", job.Metadata.Name,
+			"number", number,
+			"err", err.Error())
+		return d, err
+	}
+	if ok && d.Since.Equal(since) {
+		return d, nil
+	}
+
+	d = store.Deadline{
+		Job:         job.Metadata.Name,
+		IssueNumber: number,
+		Since:       since,
+		At:          since.AddDate(0, 0, job.Spec.In),
+	}
+	if err := store.PutDeadline(global.Store, d); err != nil {
+		global.Sugar.Errorw("save deadline",
+			"deadline", d,
+			"err", err.Error())
+		return d, err
+	}
+	return d, nil
+}
+
+// 获取延期指令对应的 job 及 issue 当前的截止时间
+func delayDeadline(number int, delay config.Delay) (store.Deadline, error) {
+	job, ok := global.Jobs[delay.Job]
+	if !ok {
+		return store.Deadline{}, fmt.Errorf("unknown job: %s", delay.Job)
+	}
+	since, err := tools.Issue.LabelAddedAt(number, job.Spec.Labels)
 	if err != nil {
-		fmt.Printf("get label create at failed. label: %v, err: %v\n", labels, err.Error())
-		return resetDate, err
+		return store.Deadline{}, fmt.Errorf("%w: %s", errNoDeadline, err.Error())
 	}
-	// 原定重置时间
-	exceptedTime := createdAt.AddDate(0, 0, in)
+	return getDeadline(job, number, since)
+}
+
+// issue 没有对应 job 的截止时间，如缺少 job 要求的 label
+var errNoDeadline = errors.New("no deadline")
 
-	if delay == 0 || instructName == "" {
-		return exceptedTime, nil
+// 延期次数检查
+// 返回值为 true，则表示通过检测，未通过时返回用于反馈的 feedback
+// 只有延期次数达到上限时才使用 delay.limitFeedback
+func checkDelay(info comm.Info, flow config.IssueComment) (bool, config.Text) {
+	delay := flow.Spec.Action.Delay
+	if delay == nil || delay.Limit <= 0 {
+		return true, nil
+	}
+	d, err := delayDeadline(info.IssueNumber, *delay)
+	if err != nil {
+		global.Sugar.Errorw("check delay",
+			"req_id", info.ReqID,
+			"delay", delay,
+			"err", err.Error())
+		if errors.Is(err, errNoDeadline) {
+			return false, global.Conf.Repository.MessageText("delay.noDeadline")
+		}
+		return false, flow.Spec.Action.FailFeedback
 	}
+	return delayWithinLimit(d, *delay)
+}
+
+// 延期次数未达到上限时通过检查
+func delayWithinLimit(d store.Deadline, delay config.Delay) (bool, config.Text) {
+	if d.Delayed >= delay.Limit {
+		return false, delay.LimitFeedback
+	}
+	return true, nil
+}
 
-	// 计算延迟后的重置时间
-	delayDate, err := LastDelayAt(owner, repository, issueNumber, delay, instructName)
+// 延长截止时间，返回延长后的截止时间
+// 延期后，会重新开始提醒
+func extendDeadline(info comm.Info, delay config.Delay) (time.Time, error) {
+	d, err := delayDeadline(info.IssueNumber, delay)
 	if err != nil {
-		fmt.Printf("it's ok. can not found last delay time. err: %v\n", err.Error())
-		return exceptedTime, nil
+		global.Sugar.Errorw("extend deadline",
+			"req_id", info.ReqID,
+			"delay", delay,
+			"err", err.Error())
+		return time.Time{}, err
 	}
-	fmt.Printf("get delay time: %v\n", delayDate.String())
-	// 返回较大的日期
-	if exceptedTime.Sub(delayDate) > 0 {
-		return exceptedTime, nil
-	} else {
-		return delayDate, nil
+	d.At = d.At.AddDate(0, 0, delay.Days)
+	d.Delayed++
+	d.RemindStage = 0
+	if err := store.PutDeadline(global.Store, d); err != nil {
+		global.Sugar.Errorw("extend deadline",
+			"req_id", info.ReqID,
+			"deadline", d,
+			"err", err.Error())
+		return time.Time{}, err
+	}
+	global.Sugar.Infow("extend deadline",
+		"req_id", info.ReqID,
+		"deadline", d)
+	return d.At, nil
+}
+
+// 根据剩余时间判断是否需要提醒
+// 每个阶段只提醒一次，并且每天最多提醒一次
+func remind(job config.Job, issue *gg.Issue, d store.Deadline, now time.Time, dryRun bool) {
+	if job.Spec.Remind.Feedback.IsEmpty() {
+		return
+	}
+	days := job.Spec.Remind.Days
+	if len(days) == 0 {
+		days = defaultRemindDays
+	}
+	stage := remindStage(days, d.At.Sub(now))
+	// 尚未进入提醒阶段
+	if stage == 0 {
+		return
+	}
+	// 该阶段已经提醒过
+	if d.RemindStage != 0 && stage >= d.RemindStage {
+		return
+	}
+	// 今天已经提醒过
+	if formatDate(d.LastRemindAt) == formatDate(now) {
+		return
+	}
+
+	info := comm.Info{}
+	info.ParseIssue(issue)
+	hc := comm.NewComment(info)
+	hc.ResetDate = formatDate(d.At)
+	global.Sugar.Infow("remind",
+		"job", job.Metadata.Name,
+		"req_id", info.ReqID,
+		"number", info.IssueNumber,
+		"stage", stage,
+		"deadline", d.At.String(),
+		"dry run", dryRun)
+	if dryRun {
+		return
+	}
+
+	tools.Issue.Comment(info.IssueNumber, hc.HandText(job.Spec.Remind.Feedback))
+	d.RemindStage = stage
+	d.LastRemindAt = now
+	if err := store.PutDeadline(global.Store, d); err != nil {
+		global.Sugar.Errorw("remind",
+			"req_id", info.ReqID,
+			"deadline", d,
+			"err", err.Error())
+	}
+}
+
+// 返回剩余时间所处的提醒阶段，即不小于剩余天数的最小阶段
+// 返回 0 表示尚未进入任何提醒阶段
+func remindStage(days []int, remaining time.Duration) int {
+	sorted := make([]int, len(days))
+	copy(sorted, days)
+	sort.Ints(sorted)
+	for _, day := range sorted {
+		if day > 0 && remaining <= time.Duration(day)*24*time.Hour {
+			return day
+		}
+	}
+	return 0
+}
+
+// 将时间格式化为日期，用于 feedback 中的 @reset-date
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
 	}
+	return t.In(global.Location).Format("2006-01-02")
 }
-*/
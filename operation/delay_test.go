@@ -0,0 +1,90 @@
+package operation
+
+import (
+	"go.uber.org/zap"
+	"issue-man/comm"
+	"issue-man/config"
+	"issue-man/global"
+	"issue-man/store"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_remindStage(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		name      string
+		days      []int
+		remaining time.Duration
+		want      int
+	}{
+		{name: "too early", days: defaultRemindDays, remaining: 10 * day, want: 0},
+		{name: "first stage", days: defaultRemindDays, remaining: 7*day + time.Hour, want: 8},
+		{name: "middle stage", days: defaultRemindDays, remaining: 3 * day, want: 4},
+		{name: "last day", days: defaultRemindDays, remaining: time.Hour, want: 1},
+		{name: "unsorted", days: []int{1, 7, 3}, remaining: 2 * day, want: 3},
+		{name: "ignore invalid", days: []int{0, -1}, remaining: time.Hour, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := remindStage(tt.days, tt.remaining); got != tt.want {
+				t.Errorf("remindStage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_checkDelay(t *testing.T) {
+	global.Conf = &config.Config{}
+	global.Sugar = zap.NewNop().Sugar()
+	global.Jobs = map[string]config.Job{}
+	limitFeedback, failFeedback := config.Text{"": "limit"}, config.Text{"": "fail"}
+	flow := config.IssueComment{}
+	flow.Spec.Action = &config.Action{FailFeedback: failFeedback}
+
+	// 不限制延期次数时不检查截止时间
+	flow.Spec.Action.Delay = &config.Delay{Job: "unknown", LimitFeedback: limitFeedback}
+	if pass, _ := checkDelay(comm.Info{}, flow); !pass {
+		t.Errorf("checkDelay() without limit = false")
+	}
+	// 配置错误时不使用 limitFeedback
+	flow.Spec.Action.Delay.Limit = 2
+	if pass, feedback := checkDelay(comm.Info{}, flow); pass || !reflect.DeepEqual(feedback, failFeedback) {
+		t.Errorf("checkDelay() unknown job = %v, %v", pass, feedback)
+	}
+
+	tests := []struct {
+		name     string
+		delayed  int
+		want     bool
+		feedback config.Text
+	}{
+		{name: "within limit", delayed: 1, want: true},
+		{name: "reach limit", delayed: 2, feedback: limitFeedback},
+		{name: "over limit", delayed: 3, feedback: limitFeedback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pass, feedback := delayWithinLimit(store.Deadline{Delayed: tt.delayed}, *flow.Spec.Action.Delay)
+			if pass != tt.want || !reflect.DeepEqual(feedback, tt.feedback) {
+				t.Errorf("delayWithinLimit() = %v, %v, want %v, %v", pass, feedback, tt.want, tt.feedback)
+			}
+		})
+	}
+}
+
+func Test_issueEdit_delayFail(t *testing.T) {
+	global.Conf = &config.Config{}
+	global.Sugar = zap.NewNop().Sugar()
+	global.Jobs = map[string]config.Job{}
+	flow := config.IssueComment{}
+	flow.Spec.Action = &config.Action{
+		AddLabels: []string{"status/delayed"},
+		Delay:     &config.Delay{Job: "unknown", Days: 7},
+	}
+	// 延期失败时返回错误，不会调用修改 issue 的 API（global.Client 为空）
+	if err := issueEdit(comm.Info{IssueNumber: 1}, flow); err == nil {
+		t.Errorf("issueEdit() err = nil, want error")
+	}
+}
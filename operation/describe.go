@@ -0,0 +1,207 @@
+package operation
+
+import (
+	"issue-man/config"
+	"issue-man/global"
+	"issue-man/tools"
+	"sort"
+	"time"
+)
+
+// Setup 描述了当前加载的配置，以及运行时获取到的信息
+type Setup struct {
+	Projects     []Project         `json:"projects"`
+	Instructions []InstructionInfo `json:"instructions"`
+	Schedules    []ScheduleInfo    `json:"schedules"`
+	Includes     []IncludeInfo     `json:"includes"`
+	Maintainers  []string          `json:"maintainers"`
+	Members      []string          `json:"members"`
+	TokenScopes  []string          `json:"tokenScopes"`
+	RateLimit    RateLimit         `json:"rateLimit"`
+	// 获取运行时信息时遇到的错误
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Project 源库、翻译库、工作库
+type Project struct {
+	Role     string `json:"role"`
+	FullName string `json:"fullName"`
+	Branch   string `json:"branch"`
+	Site     string `json:"site"`
+}
+
+// InstructionInfo 指令的权限、要求的 label 以及效果
+type InstructionInfo struct {
+	Instruct    string   `json:"instruct"`
+	Permissions []string `json:"permissions"`
+	Labels      []string `json:"labels"`
+	Effect      string   `json:"effect"`
+}
+
+// ScheduleInfo 定时任务及其下次执行时间
+type ScheduleInfo struct {
+	Name string    `json:"name"`
+	Spec string    `json:"spec"`
+	Next time.Time `json:"next"`
+	// 仅对 Job 有效
+	In     int      `json:"in,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+	DryRun bool     `json:"dryRun,omitempty"`
+}
+
+// IncludeInfo 创建 issue 的规则，以及源库中符合规则的文件示例
+type IncludeInfo struct {
+	Path     string   `json:"path"`
+	Excludes []string `json:"excludes"`
+	Labels   []string `json:"labels"`
+	Examples []string `json:"examples"`
+}
+
+// RateLimit token 剩余的调用次数
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// Describe 描述当前加载的配置
+// examples 为每条 include 规则最多列出的示例文件数量，为 0 时不获取源库的文件列表
+func Describe(examples int) Setup {
+	c := global.Conf
+	setup := Setup{
+		Projects: []Project{
+			project("source", c.Repository.Spec.Source),
+			project("translate", c.Repository.Spec.Translate),
+			project("workspace", config.Selector{
+				Owner:      c.Repository.Spec.Workspace.Owner,
+				Repository: c.Repository.Spec.Workspace.Repository,
+			}),
+		},
+		Instructions: describeInstructions(),
+		Schedules:    describeSchedules(),
+		Maintainers:  sortedKeys(global.Maintainers),
+		Members:      sortedKeys(global.Members),
+		Errors:       make([]string, 0),
+	}
+
+	setup.Includes = make([]IncludeInfo, 0, len(c.IssueCreate.Spec.Includes))
+	var files []string
+	if examples > 0 && len(c.IssueCreate.Spec.Includes) > 0 {
+		fs, err := tools.Tree.GetAllMatchFile(c.Repository.Spec.Source.Branch)
+		if err != nil {
+			setup.Errors = append(setup.Errors, err.Error())
+		}
+		for file := range fs {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+	}
+	for _, include := range c.IssueCreate.Spec.Includes {
+		info := IncludeInfo{
+			Path:     include.Path,
+			Excludes: make([]string, 0, len(include.Exclude)),
+			Labels:   include.Labels,
+			Examples: make([]string, 0),
+		}
+		for _, v := range include.Exclude {
+			info.Excludes = append(info.Excludes, v.Path)
+		}
+		for _, file := range files {
+			if len(info.Examples) >= examples {
+				break
+			}
+			if m, ok := c.IssueCreate.Explain(file); ok && m.Chain[0].Path == include.Path {
+				info.Examples = append(info.Examples, file)
+			}
+		}
+		setup.Includes = append(setup.Includes, info)
+	}
+
+	rate, scopes, err := tools.Account.RateLimit()
+	if err != nil {
+		setup.Errors = append(setup.Errors, err.Error())
+	} else {
+		setup.TokenScopes = scopes
+		setup.RateLimit = RateLimit{Limit: rate.Limit, Remaining: rate.Remaining, Reset: rate.Reset.Time}
+	}
+	return setup
+}
+
+func project(role string, s config.Selector) Project {
+	return Project{Role: role, FullName: s.GetFullName(), Branch: s.Branch, Site: s.Site}
+}
+
+// 指令列表，按指令名排序，包括内置指令
+func describeInstructions() []InstructionInfo {
+	langs := global.Conf.Repository.Languages("", nil)
+	msg := func(key string) string {
+		return global.Conf.Repository.Message(key, langs...)
+	}
+
+	list := make([]InstructionInfo, 0, len(global.Instructions)+len(builtins))
+	for instruct, flow := range global.Instructions {
+		info := InstructionInfo{Instruct: instruct, Effect: describeAction(flow.Spec.Action, msg)}
+		if flow.Spec.Rules != nil {
+			info.Permissions = flow.Spec.Rules.Permissions
+			info.Labels = flow.Spec.Rules.Labels
+		}
+		list = append(list, info)
+	}
+	for instruct := range builtins {
+		if _, ok := global.Instructions[instruct]; !ok {
+			list = append(list, InstructionInfo{Instruct: instruct, Permissions: []string{tools.Anyone}, Effect: "built-in"})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Instruct < list[j].Instruct
+	})
+	return list
+}
+
+// 定时任务列表，与 Sync() 启动的定时任务一致
+func describeSchedules() []ScheduleInfo {
+	list := make([]ScheduleInfo, 0, len(global.Jobs)+1)
+	detection, err := global.Conf.Repository.DetectionSchedule()
+	if err != nil {
+		return list
+	}
+	if global.Conf.Repository.Spec.Workspace.Detection.Enable {
+		list = append(list, ScheduleInfo{Name: "detection", Spec: detection, Next: nextRun(detection)})
+	}
+	for name, job := range global.Jobs {
+		spec := job.Spec.Schedule
+		if spec == "" {
+			spec = detection
+		}
+		list = append(list, ScheduleInfo{
+			Name:   "job/" + name,
+			Spec:   spec,
+			Next:   nextRun(spec),
+			In:     job.Spec.In,
+			Labels: job.Spec.Labels,
+			DryRun: job.Spec.DryRun,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// 下次执行时间，不包括随机延迟
+func nextRun(spec string) time.Time {
+	schedule, err := config.ParseSchedule(spec)
+	if err != nil {
+		return time.Time{}
+	}
+	return schedule.Next(time.Now().In(global.Location))
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,133 @@
+package operation
+
+import (
+	"fmt"
+	"issue-man/comm"
+	"issue-man/global"
+	"issue-man/store"
+	"issue-man/tools"
+	"strings"
+)
+
+// 内置指令，预览某条指令的执行结果，例如 /explain /accept
+const ExplainInstruct = "explain"
+
+// explain 的结果
+type explainResult struct {
+	Instruct string
+	// 各项检查的结果，key 为检查项
+	Checks map[string]bool
+	Undo   bool
+	Before store.IssueState
+	After  store.IssueState
+	// 将会发送的反馈
+	Feedback string
+}
+
+// explain 按照 do() 的流程执行指令，但不会对 issue 做出任何改动
+// 回复各项检查的结果、将会产生的改动以及将会发送的反馈
+func explain(info comm.Info) {
+	langs := comm.NewComment(info).Languages()
+	msg := func(key string) string {
+		return global.Conf.Repository.Message(key, langs...)
+	}
+
+	if len(info.Args) == 0 {
+		tools.Issue.Comment(info.IssueNumber, msg("explain.usage"))
+		return
+	}
+	instruct := strings.TrimPrefix(info.Args[0], "/")
+	flow, ok := global.Instructions[instruct]
+	if !ok {
+		tools.Issue.Comment(info.IssueNumber, fmt.Sprintf(msg("explain.unknown"), instruct))
+		return
+	}
+	info.Instruct = instruct
+	info.Args = info.Args[1:]
+
+	result := explainResult{
+		Instruct: instruct,
+		Checks:   make(map[string]bool),
+		Undo:     flow.Spec.Action.Undo,
+		Before:   newIssueState(info.Labels, info.Assignees, info.State),
+	}
+	failed := false
+	for _, rule := range checkRules {
+		pass, hc, feedback := check(rule, info, flow)
+		result.Checks[rule] = pass
+		// 与 do() 一致，反馈第一项未通过的检查
+		if !pass && !failed {
+			failed = true
+			result.Feedback = hc.HandText(feedback)
+		}
+	}
+
+	edit := genIssueRequest(info, flow)
+	result.After = newIssueState(*edit.Labels, *edit.Assignees, edit.GetState())
+	if !failed && !result.Undo {
+		hc := comm.NewComment(info)
+		if flow.Spec.Action.Delay != nil {
+			if d, err := delayDeadline(info.IssueNumber, *flow.Spec.Action.Delay); err == nil {
+				hc.ResetDate = formatDate(d.At.AddDate(0, 0, flow.Spec.Action.Delay.Days))
+			}
+		}
+		result.Feedback = hc.HandText(flow.Spec.Action.SuccessFeedback)
+	}
+
+	global.Sugar.Infow("explain instruct",
+		"req_id", info.ReqID,
+		"result", result)
+	tools.Issue.Comment(info.IssueNumber, explainText(result, msg))
+}
+
+// 生成 explain 的回复内容
+func explainText(r explainResult, msg func(key string) string) string {
+	b := strings.Builder{}
+	b.WriteString(fmt.Sprintf(msg("explain.title"), r.Instruct))
+	b.WriteString(msg("explain.header"))
+	for _, rule := range checkRules {
+		result := msg("explain.pass")
+		if !r.Checks[rule] {
+			result = msg("explain.fail")
+		}
+		b.WriteString(fmt.Sprintf("| %s | %s |\n", rule, result))
+	}
+	b.WriteString("\n")
+
+	if r.Undo {
+		b.WriteString(msg("help.undo"))
+		b.WriteString("\n\n")
+	} else {
+		b.WriteString(fmt.Sprintf(msg("explain.labels"), delta(r.Before.Labels, r.After.Labels, msg)))
+		b.WriteString(fmt.Sprintf(msg("explain.assignees"), delta(r.Before.Assignees, r.After.Assignees, msg)))
+		b.WriteString(fmt.Sprintf(msg("explain.state"), r.Before.State, r.After.State))
+	}
+
+	if r.Feedback == "" {
+		b.WriteString(msg("explain.noFeedback"))
+	} else {
+		b.WriteString(msg("explain.feedback"))
+		b.WriteString("> " + strings.ReplaceAll(r.Feedback, "\n", "\n> "))
+	}
+	return b.String()
+}
+
+// 列出新增（+）和移除（-）的内容，均已排序
+func delta(before, after []string, msg func(key string) string) string {
+	beforeMap, afterMap := tools.Convert.StringToMap(before), tools.Convert.StringToMap(after)
+	changes := make([]string, 0)
+	for _, v := range after {
+		if !beforeMap[v] {
+			changes = append(changes, fmt.Sprintf("+`%s`", v))
+		}
+	}
+	for _, v := range before {
+		if !afterMap[v] {
+			changes = append(changes, fmt.Sprintf("-`%s`", v))
+		}
+	}
+	if len(changes) == 0 {
+		return msg("help.none")
+	}
+	return strings.Join(changes, " ")
+}
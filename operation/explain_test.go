@@ -0,0 +1,31 @@
+package operation
+
+import (
+	"issue-man/config"
+	"issue-man/global"
+	"testing"
+)
+
+func Test_delta(t *testing.T) {
+	global.Conf = &config.Config{}
+	msg := func(key string) string {
+		return global.Conf.Repository.Message(key, "en")
+	}
+	tests := []struct {
+		name   string
+		before []string
+		after  []string
+		want   string
+	}{
+		{name: "no change", before: []string{"a"}, after: []string{"a"}, want: "none"},
+		{name: "add and remove", before: []string{"a", "b"}, after: []string{"b", "c"}, want: "+`c` -`a`"},
+		{name: "empty before", before: nil, after: []string{"a"}, want: "+`a`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := delta(tt.before, tt.after, msg); got != tt.want {
+				t.Errorf("delta() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package operation
 import (
 	"gopkg.in/go-playground/webhooks.v5/github"
 	"issue-man/comm"
+	"issue-man/config"
 	"issue-man/global"
 	"issue-man/tools"
 )
@@ -12,6 +13,19 @@ import (
 // value 为提及人员，可能为空
 func IssueHanding(payload github.IssueCommentPayload, instructs map[string][]string) {
 	for instruct, mention := range instructs {
+		// 配置的指令优先于内置指令
+		if _, ok := global.Instructions[instruct]; !ok && builtins[instruct] != nil {
+			info := comm.Info{}
+			info.Parse(payload)
+			info.Mention = mention
+			info.Instruct = instruct
+			info.Args = tools.Parse.InstructArgs(payload.Comment.Body)[instruct]
+			global.Sugar.Debugw("do builtin instruct",
+				"req_id", info.ReqID,
+				"instruct", instruct)
+			builtins[instruct](info)
+			continue
+		}
 		if _, ok := global.Instructions[instruct]; !ok {
 			global.Sugar.Errorw("unknown instruction",
 				"instruction", instruct,
@@ -26,6 +40,8 @@ func IssueHanding(payload github.IssueCommentPayload, instructs map[string][]str
 // 权限检查
 // 状态检查
 // 数量检查
+// 延期次数检查
+// 撤销（如果是撤销指令的话）
 // 拼装数据
 // 发送 Edit Issue 请求
 // 发送 Move Card 请求（如果有的话）
@@ -38,6 +54,8 @@ func do(instruct string, mention []string, payload github.IssueCommentPayload) {
 	info.Parse(payload)
 
 	info.Mention = mention
+	info.Instruct = instruct
+	info.Args = tools.Parse.InstructArgs(payload.Comment.Body)[instruct]
 	flow := global.Instructions[instruct]
 
 	global.Sugar.Debugw("do instruct",
@@ -46,60 +64,64 @@ func do(instruct string, mention []string, payload github.IssueCommentPayload) {
 		"mention", mention,
 		"info", info)
 
-	// 权限检查
-	if !tools.Verify.Permission(flow.Spec.Rules.Permissions, info.Login, info.Assignees) {
-		global.Sugar.Infow("do instruct",
-			"req_id", info.ReqID,
-			"step", "Permission",
-			"status", "fail",
-			"info", info,
-			"require", flow.Spec.Rules.Permissions)
-		hc := comm.Comment{
-			Login: info.Login,
-			ReqID: info.ReqID,
+	// 依次检查，未通过时反馈并结束
+	for _, rule := range checkRules {
+		pass, hc, feedback := check(rule, info, flow)
+		if pass {
+			continue
 		}
-		// 如果 feedback 为空不会做任何操作
-		tools.Issue.Comment(info.IssueNumber, hc.HandComment(flow.Spec.Rules.PermissionFeedback))
-		return
-	}
-
-	// 标签（状态）检查
-	if !tools.Verify.HasLabel(flow.Spec.Rules.Labels, info.Labels) {
 		global.Sugar.Infow("do instruct",
 			"req_id", info.ReqID,
-			"step", "CheckLabel",
+			"step", rule,
 			"status", "fail",
 			"info", info,
-			"require", flow.Spec.Rules.Labels)
-		hc := comm.Comment{
-			Login: info.Login,
-			ReqID: info.ReqID,
-		}
+			"require", hc.Require,
+			"requireCount", hc.LimitCount)
 		// 如果 feedback 为空不会做任何操作
-		tools.Issue.Comment(info.IssueNumber, hc.HandComment(flow.Spec.Rules.LabelFeedback))
+		tools.Issue.Comment(info.IssueNumber, hc.HandText(feedback))
 		return
 	}
 
-	// 数量检查
-	if !tools.Verify.LabelCount(info.Login, flow.Spec.Action.AddLabels, flow.Spec.Action.AddLabelsLimit) {
-		global.Sugar.Infow("do instruct",
-			"req_id", info.ReqID,
-			"step", "CheckCount",
-			"status", "fail",
-			"requireCount", flow.Spec.Action.AddLabelsLimit)
-		hc := comm.Comment{
-			Login:      info.Login,
-			ReqID:      info.ReqID,
-			LimitCount: flow.Spec.Action.AddLabelsLimit,
-		}
-		// 如果 feedback 为空不会做任何操作
-		tools.Issue.Comment(info.IssueNumber, hc.HandComment(flow.Spec.Action.LabelLimitFeedback))
+	// 撤销指令
+	if flow.Spec.Action.Undo {
+		undo(info)
 		return
 	}
 
 	// 发送 Update Issue 请求（如果有的话）
-	issueEdit(info, flow)
+	_ = issueEdit(info, flow)
 
 	// 发送 Move Card 请求（如果有的话）
 	//CardMove(info, flow)
 }
+
+// 执行指令前的检查项，按顺序执行
+var checkRules = []string{"Permission", "CheckLabel", "CheckCount", "CheckDelay"}
+
+// 执行某一项检查，不会对 issue 做出改动
+// 返回是否通过检查，以及未通过时用于反馈的 comment 和 feedback
+func check(rule string, info comm.Info, flow config.IssueComment) (bool, comm.Comment, config.Text) {
+	hc := comm.NewComment(info)
+	hc.Rule = rule
+	rules, action := flow.Spec.Rules, flow.Spec.Action
+	switch rule {
+	// 权限检查
+	case "Permission":
+		hc.Require = rules.Permissions
+		return tools.Verify.Permission(rules.Permissions, info.Login, info.Assignees), hc, rules.PermissionFeedback
+	// 标签（状态）检查
+	case "CheckLabel":
+		hc.Require = rules.Labels
+		return tools.Verify.HasLabel(rules.Labels, info.Labels), hc, rules.LabelFeedback
+	// 数量检查
+	case "CheckCount":
+		hc.Require = action.AddLabels
+		hc.LimitCount = action.AddLabelsLimit
+		return tools.Verify.LabelCount(info.Login, action.AddLabels, action.AddLabelsLimit), hc, action.LabelLimitFeedback
+	// 延期次数检查
+	case "CheckDelay":
+		pass, feedback := checkDelay(info, flow)
+		return pass, hc, feedback
+	}
+	return true, hc, nil
+}
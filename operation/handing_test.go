@@ -0,0 +1,33 @@
+package operation
+
+import (
+	"go.uber.org/zap"
+	"gopkg.in/go-playground/webhooks.v5/github"
+	"issue-man/comm"
+	"issue-man/config"
+	"issue-man/global"
+	"reflect"
+	"testing"
+)
+
+func TestIssueHanding_builtinArgs(t *testing.T) {
+	global.Conf = &config.Config{}
+	global.Sugar = zap.NewNop().Sugar()
+	global.Instructions = make(map[string]config.IssueComment)
+
+	var got comm.Info
+	origin := builtins[ExplainInstruct]
+	builtins[ExplainInstruct] = func(info comm.Info) { got = info }
+	defer func() { builtins[ExplainInstruct] = origin }()
+
+	payload := github.IssueCommentPayload{}
+	payload.Comment.Body = "/explain /accept @alice"
+	IssueHanding(payload, map[string][]string{ExplainInstruct: {"alice"}})
+
+	if got.Instruct != ExplainInstruct {
+		t.Fatalf("builtin not dispatched, got instruct %q", got.Instruct)
+	}
+	if want := []string{"/accept", "@alice"}; !reflect.DeepEqual(got.Args, want) {
+		t.Errorf("Args = %v, want %v", got.Args, want)
+	}
+}
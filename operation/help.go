@@ -0,0 +1,131 @@
+package operation
+
+import (
+	"fmt"
+	"issue-man/comm"
+	"issue-man/config"
+	"issue-man/global"
+	"issue-man/tools"
+	"sort"
+	"strings"
+)
+
+const (
+	// 内置指令，如果配置了同名指令，则以配置为准
+	HelpInstruct   = "help"
+	StatusInstruct = "status"
+)
+
+// 内置指令及其处理函数
+var builtins = map[string]func(info comm.Info){
+	HelpInstruct:    help,
+	StatusInstruct:  status,
+	ExplainInstruct: explain,
+}
+
+// help 列出所有已配置的指令
+func help(info comm.Info) {
+	hc := comm.NewComment(info)
+	tools.Issue.Comment(info.IssueNumber, helpTable(global.Instructions, hc.Languages()...))
+}
+
+// status 显示 issue 的当前状态，以及评论者当前可以执行的指令
+// 仅做检查，不会做出任何改动
+func status(info comm.Info) {
+	langs := comm.NewComment(info).Languages()
+	msg := func(key string) string {
+		return global.Conf.Repository.Message(key, langs...)
+	}
+
+	available := make([]string, 0)
+	for instruct, flow := range global.Instructions {
+		if flow.Spec.Rules == nil {
+			continue
+		}
+		if tools.Verify.Permission(flow.Spec.Rules.Permissions, info.Login, info.Assignees) &&
+			tools.Verify.HasLabel(flow.Spec.Rules.Labels, info.Labels) {
+			available = append(available, instruct)
+		}
+	}
+	sort.Strings(available)
+
+	body := fmt.Sprintf(msg("status.current"), info.State, codeList(info.Labels, msg), codeList(info.Assignees, msg))
+	if len(available) == 0 {
+		body += fmt.Sprintf(msg("status.unavailable"), info.Login)
+	} else {
+		for k := range available {
+			available[k] = "/" + available[k]
+		}
+		body += fmt.Sprintf(msg("status.available"), info.Login, codeList(available, msg))
+	}
+	tools.Issue.Comment(info.IssueNumber, body)
+}
+
+// 生成指令的 markdown 表格，按指令名排序
+func helpTable(instructions map[string]config.IssueComment, langs ...string) string {
+	msg := func(key string) string {
+		return global.Conf.Repository.Message(key, langs...)
+	}
+
+	names := make([]string, 0, len(instructions))
+	for name := range instructions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b := strings.Builder{}
+	b.WriteString(msg("help.header"))
+	for _, name := range names {
+		flow := instructions[name]
+		permissions, labels := make([]string, 0), make([]string, 0)
+		if flow.Spec.Rules != nil {
+			permissions = flow.Spec.Rules.Permissions
+			labels = flow.Spec.Rules.Labels
+		}
+		b.WriteString(fmt.Sprintf("| `/%s` | %s | %s | %s |\n",
+			name, codeList(permissions, msg), codeList(labels, msg), describeAction(flow.Spec.Action, msg)))
+	}
+	b.WriteString(msg("help.builtin"))
+	return b.String()
+}
+
+// 描述指令对 issue 做出的改动
+func describeAction(action *config.Action, msg func(key string) string) string {
+	if action == nil {
+		return msg("help.none")
+	}
+	effects := make([]string, 0)
+	add := func(key string, values []string) {
+		if len(values) > 0 {
+			effects = append(effects, fmt.Sprintf(msg(key), codeList(values, msg)))
+		}
+	}
+	add("help.addLabels", action.AddLabels)
+	add("help.removeLabels", action.RemoveLabels)
+	add("help.addAssignees", action.AddAssignees)
+	add("help.removeAssignees", action.RemoveAssignees)
+	if action.State != "" {
+		effects = append(effects, fmt.Sprintf(msg("help.state"), fmt.Sprintf("`%s`", action.State)))
+	}
+	if action.Delay != nil {
+		effects = append(effects, fmt.Sprintf(msg("help.delay"), fmt.Sprintf("`%s`", action.Delay.Job), action.Delay.Days))
+	}
+	if action.Undo {
+		effects = append(effects, msg("help.undo"))
+	}
+	if len(effects) == 0 {
+		return msg("help.none")
+	}
+	return strings.Join(effects, "<br>")
+}
+
+func codeList(values []string, msg func(key string) string) string {
+	if len(values) == 0 {
+		return msg("help.none")
+	}
+	list := make([]string, 0, len(values))
+	for _, v := range values {
+		list = append(list, fmt.Sprintf("`%s`", v))
+	}
+	return strings.Join(list, ", ")
+}
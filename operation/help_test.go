@@ -0,0 +1,39 @@
+package operation
+
+import (
+	"issue-man/config"
+	"issue-man/global"
+	"testing"
+)
+
+func Test_describeAction(t *testing.T) {
+	global.Conf = &config.Config{}
+	msg := func(key string) string {
+		return global.Conf.Repository.Message(key, "en")
+	}
+	tests := []struct {
+		name   string
+		action *config.Action
+		want   string
+	}{
+		{name: "nil", action: nil, want: "none"},
+		{name: "empty", action: &config.Action{}, want: "none"},
+		{
+			name:   "labels and state",
+			action: &config.Action{AddLabels: []string{"status/finished"}, State: "closed"},
+			want:   "add labels `status/finished`<br>set the issue state to `closed`",
+		},
+		{
+			name:   "delay",
+			action: &config.Action{Delay: &config.Delay{Job: "reset", Days: 7}},
+			want:   "extend the deadline of `reset` by 7 days",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeAction(tt.action, msg); got != tt.want {
+				t.Errorf("describeAction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
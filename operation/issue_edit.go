@@ -1,9 +1,11 @@
 package operation
 
 import (
+	"errors"
 	gg "github.com/google/go-github/v30/github"
 	"issue-man/comm"
 	"issue-man/config"
+	"issue-man/global"
 	"issue-man/tools"
 )
 
@@ -19,7 +21,41 @@ const (
 // 具体修改内容完全取决于配置文件
 // 但是，一般来说，改动的内容只涉及 label，assignees，state
 // 而 title，body，milestone 不会改变
-func issueEdit(info comm.Info, flow config.IssueComment) {
+func issueEdit(info comm.Info, flow config.IssueComment) error {
+	comment := comm.NewComment(info)
+	// 延期指令，先延长截止时间，并在 feedback 中提示新的截止时间
+	// 失败时反馈失败的原因，不修改 issue，也不记录改动
+	if flow.Spec.Action.Delay != nil {
+		resetDate, err := extendDeadline(info, *flow.Spec.Action.Delay)
+		if err != nil {
+			feedback := flow.Spec.Action.FailFeedback
+			if errors.Is(err, errNoDeadline) {
+				feedback = global.Conf.Repository.MessageText("delay.noDeadline")
+			}
+			tools.Issue.Comment(info.IssueNumber, comment.HandText(feedback))
+			return err
+		}
+		comment.ResetDate = formatDate(resetDate)
+	}
+
+	edit := genIssueRequest(info, flow)
+
+	// 尝试调用更新接口
+	updated, err := tools.Issue.EditByIssueRequest(info.IssueNumber, edit)
+	if err != nil {
+		return err
+	}
+	// 记录改动，用于撤销
+	record(info, updated)
+
+	// 如果 feedback 为空不会做任何操作
+	tools.Issue.Comment(info.IssueNumber, comment.HandText(flow.Spec.Action.SuccessFeedback))
+	return nil
+}
+
+// 根据 info 和 flow 生成修改 issue 的请求
+// 仅生成请求，不会调用 API
+func genIssueRequest(info comm.Info, flow config.IssueComment) *gg.IssueRequest {
 	// 一般不会变化的内容
 	edit := &gg.IssueRequest{
 		Title:     &info.Title,
@@ -41,34 +77,14 @@ func issueEdit(info comm.Info, flow config.IssueComment) {
 	// 更新 assignees（如果有的话）
 	updateAssign(edit, info, flow)
 
-	// 尝试调用更新接口
-	_, err := tools.Issue.EditByIssueRequest(info.IssueNumber, edit)
-	if err != nil {
-		return
-	}
-
-	comment := comm.Comment{}
-	comment.ReqID = info.ReqID
-	comment.Login = info.Login
-	comment.Assignees = info.Assignees
-	// 这可能是一个修改重置时间的指令，解析其重置时间
-	//if flow.JobName == "reset" {
-	//	if Sync, ok := global.Jobs[flow.JobName]; ok {
-	//		resetDate, err := getResetDate(info.Owner, info.Repository, info.IssueNumber, flow.CurrentLabel, int(Sync.In), int(flow.Delay), flow.Name)
-	//		if err != nil {
-	//			fmt.Printf("get reset date for instruct failed. instruct: %v, err: %v\n", flow.Name, err.Error())
-	//			return
-	//		}
-	//		comment.ResetDate = resetDate.In(time.Local).Format("2006-01-02")
-	//	}
-	//}
-	// 如果 feedback 为空不会做任何操作
-	tools.Issue.Comment(info.IssueNumber, comment.HandComment(flow.Spec.Action.SuccessFeedback))
+	return edit
 }
 
 // 根据 flow 更新 info 中的 label
 func updateLabel(req *gg.IssueRequest, info comm.Info, flow config.IssueComment) {
 	req.Labels = tools.Convert.SliceAdd(tools.Convert.SliceRemove(tools.Get.Strings(info.Labels), flow.Spec.Action.RemoveLabels...), flow.Spec.Action.AddLabels...)
+	// 移除与新增 label 互斥的 label
+	req.Labels = tools.Get.Strings(global.Conf.Repository.ExclusiveLabels(*req.Labels, flow.Spec.Action.AddLabels...))
 }
 
 // 根据 flow 更新 info 中的 assignees
@@ -0,0 +1,76 @@
+package operation
+
+import (
+	"fmt"
+	"issue-man/global"
+	"issue-man/store"
+	"issue-man/tools"
+)
+
+// 存储结构的升级，按版本号依次执行，已执行过的不会再次执行
+// 新增的 Migration 只能追加在末尾，且版本号递增
+var migrations = []store.Migration{
+	{
+		Version:     1,
+		Description: "import detection cursor from the body of pr issue",
+		Up:          importCursor,
+	},
+	{
+		Version:     2,
+		Description: "build file-to-issue index from workspace issues",
+		Up:          buildIndex,
+	},
+}
+
+// Migrate 执行尚未执行的存储升级
+func Migrate() error {
+	applied, err := store.Migrate(global.Store, migrations)
+	for _, m := range applied {
+		global.Sugar.Infow("migrate store",
+			"version", m.Version,
+			"description", m.Description)
+	}
+	if err != nil {
+		global.Sugar.Errorw("migrate store",
+			"status", "fail",
+			"err", err.Error())
+	}
+	return err
+}
+
+// 旧版本的检测进度保存在 pr issue 的 body 中
+func importCursor(s store.Store) error {
+	detection := global.Conf.Repository.Spec.Workspace.Detection
+	if detection.PRIssue == 0 {
+		return nil
+	}
+	if _, ok, err := store.GetCursor(s, store.DetectionCursor); err != nil || ok {
+		return err
+	}
+	prIssue := tools.Issue.GetPRIssue()
+	if prIssue == nil {
+		return fmt.Errorf("load pr issue %d fail", detection.PRIssue)
+	}
+	cursor := cursorFromBody(prIssue.GetBody())
+	// 尚未初始化的 pr issue
+	if cursor.PRNumber == 0 && cursor.SHA == "" {
+		return nil
+	}
+	return store.PutCursor(s, store.DetectionCursor, cursor)
+}
+
+// 根据现有 issue 的 body 建立文件与 issue 的索引
+func buildIndex(s store.Store) error {
+	issues, err := tools.Issue.GetAllMath()
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		for _, file := range tools.Parse.FilesFromBody(issue.GetBody()) {
+			if err := store.PutIssueIndex(s, file, issue.GetNumber()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
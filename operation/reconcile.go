@@ -0,0 +1,96 @@
+package operation
+
+import (
+	"issue-man/global"
+	"issue-man/tools"
+	"sort"
+	"time"
+)
+
+// ReconcileResult 记录了一个违反互斥规则的 issue 及其处理结果
+// 在 dry-run 模式下，表示将要做出的改动
+type ReconcileResult struct {
+	IssueNumber int                 `json:"issueNumber"`
+	Title       string              `json:"title"`
+	Conflicts   map[string][]string `json:"conflicts"`
+	Labels      []string            `json:"labels"`
+	NewLabels   []string            `json:"newLabels"`
+	DryRun      bool                `json:"dryRun"`
+}
+
+// Reconcile 检查所有 open issue 的 label 是否违反互斥规则
+// 对于违反规则的 issue，每组仅保留最晚添加的 label
+// dryRun 为 true 时，仅返回将要做出的改动，不会调用修改相关的 API
+func Reconcile(dryRun bool) []ReconcileResult {
+	results := make([]ReconcileResult, 0)
+	if len(global.Conf.Repository.Spec.LabelGroups) == 0 {
+		return results
+	}
+
+	issues, err := tools.Issue.ListByLabels(nil)
+	if err != nil {
+		return results
+	}
+	for _, issue := range issues {
+		if issue.IsPullRequest() || issue.Labels == nil {
+			continue
+		}
+		labels := *tools.Convert.Label(issue.Labels)
+		conflicts := global.Conf.Repository.LabelConflicts(labels)
+		if len(conflicts) == 0 {
+			continue
+		}
+
+		events, err := tools.Issue.ListEvents(issue.GetNumber())
+		if err != nil {
+			continue
+		}
+		// 保留每组中最晚添加的 label
+		keep := make([]string, 0, len(conflicts))
+		for _, group := range conflicts {
+			keep = append(keep, latestLabel(group, func(label string) (time.Time, error) {
+				return tools.Parse.LabelAddedAt(events, []string{label})
+			}))
+		}
+		sort.Strings(keep)
+		newLabels := global.Conf.Repository.ExclusiveLabels(labels, keep...)
+		sort.Strings(labels)
+		sort.Strings(newLabels)
+
+		result := ReconcileResult{
+			IssueNumber: issue.GetNumber(),
+			Title:       issue.GetTitle(),
+			Conflicts:   conflicts,
+			Labels:      labels,
+			NewLabels:   newLabels,
+			DryRun:      dryRun,
+		}
+		results = append(results, result)
+		global.Sugar.Infow("reconcile labels",
+			"result", result)
+		if dryRun {
+			continue
+		}
+
+		edit := tools.Convert.Issue(issue)
+		edit.Labels = &newLabels
+		_, _ = tools.Issue.EditByIssueRequest(issue.GetNumber(), edit)
+	}
+	return results
+}
+
+// 返回最晚添加的 label，无法获取添加时间的 label 视为最早添加
+// 添加时间相同时，取排序靠前的 label
+func latestLabel(labels []string, addedAt func(label string) (time.Time, error)) string {
+	latest, latestAt := labels[0], time.Time{}
+	for _, label := range labels {
+		at, err := addedAt(label)
+		if err != nil {
+			continue
+		}
+		if at.After(latestAt) {
+			latest, latestAt = label, at
+		}
+	}
+	return latest
+}
@@ -0,0 +1,94 @@
+package operation
+
+import (
+	"fmt"
+	"issue-man/comm"
+	"issue-man/config"
+	"issue-man/global"
+	"issue-man/store"
+	"issue-man/tools"
+	"time"
+)
+
+// ReplayOptions 手动同步的范围
+// 指定 PR 时，同步该 pr 涉及的文件；否则同步 From 之后到 To 之间的 commit 涉及的文件
+type ReplayOptions struct {
+	PR   int
+	From string
+	// 默认为 source.branch
+	To     string
+	DryRun bool
+}
+
+// Replay 手动同步指定的 pr 或 commit 范围，用于补充处理漏掉的 pr
+// 不会读取和修改检测进度，也不会跳过之前已处理的文件
+func Replay(opt ReplayOptions) (report store.SyncReport, err error) {
+	report = store.SyncReport{
+		Branch:    global.Conf.Repository.Spec.Source.Branch,
+		StartedAt: time.Now(),
+		Units:     make([]store.SyncUnitReport, 0),
+	}
+	defer func() {
+		report.FinishedAt = time.Now()
+	}()
+
+	var units []syncUnit
+	switch {
+	case opt.PR != 0:
+		report.Mode = config.DetectionPR
+		pr, err := tools.PR.Get(opt.PR)
+		if err != nil {
+			return report, err
+		}
+		if pr.MergedAt == nil {
+			return report, fmt.Errorf("pull request #%d is not merged", opt.PR)
+		}
+		files, err := getAssociatedFiles(pr)
+		if err != nil {
+			return report, err
+		}
+		report.Branch = pr.GetBase().GetRef()
+		units = []syncUnit{{
+			cursor: store.Cursor{PRNumber: pr.GetNumber(), SHA: pr.GetMergeCommitSHA(), MergedAt: pr.GetMergedAt()},
+			files:  files,
+		}}
+	case opt.From != "":
+		report.Mode = config.DetectionCommit
+		if opt.To == "" {
+			opt.To = global.Conf.Repository.Spec.Source.Branch
+		}
+		report.Branch = opt.To
+		if units, err = commitUnits(opt.From, opt.To, 0); err != nil {
+			return report, err
+		}
+	default:
+		return report, fmt.Errorf("either pr or from is required")
+	}
+	if len(units) > 0 {
+		report.From = store.Cursor{SHA: opt.From}
+		report.To = units[len(units)-1].cursor
+	}
+
+	// 与定时检测互斥，避免重复处理
+	lock.Lock()
+	defer lock.Unlock()
+
+	existIssues, err := tools.Issue.GetAllMath()
+	if err != nil {
+		return report, err
+	}
+	limiter := time.NewTicker(time.Millisecond * 500)
+	defer limiter.Stop()
+	// 与定时检测使用相同的 key，从相同的起点重新同步时，修改之前的 comment
+	// 同步 pr 时，以该 pr 的 merge commit 为起点
+	from := opt.From
+	if opt.PR != 0 && len(units) > 0 {
+		from = units[0].cursor.SHA
+	}
+	digest := comm.NewDigest(report.Branch, from)
+	defer digest.Flush()
+	for _, u := range units {
+		report.Units = append(report.Units, syncFiles(u, existIssues, limiter.C, syncOptions{dryRun: opt.DryRun, digest: digest}))
+	}
+	return report, nil
+}
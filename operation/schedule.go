@@ -0,0 +1,173 @@
+package operation
+
+import (
+	"github.com/robfig/cron/v3"
+	"issue-man/config"
+	"issue-man/global"
+	"issue-man/store"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Schedule 表示一个按 cron 表达式定时执行的任务
+// 同步检测、每个 Job 都是一个 Schedule
+type Schedule struct {
+	Name     string    `json:"name"`
+	Spec     string    `json:"spec"`
+	Timezone string    `json:"timezone"`
+	Next     time.Time `json:"next"`
+	LastRun  time.Time `json:"lastRun"`
+
+	schedule cron.Schedule
+	run      func()
+}
+
+var (
+	// 已启动的定时任务，以及保护其字段的锁
+	schedules     = make(map[string]*Schedule)
+	scheduleMutex sync.Mutex
+
+	// 同一时间只执行一个定时任务
+	runMutex sync.Mutex
+)
+
+// Sync 根据配置启动所有定时任务
+// 1. 同步检测，执行时间为 detection.schedule
+// 2. 每个 Job，执行时间为 Job.Spec.Schedule，默认与同步检测相同
+// 服务停止期间错过的执行，会在启动后立即补执行一次
+func Sync() {
+	global.Sugar.Infow("loaded jobs", "list", global.Jobs)
+	jitter, err := global.Conf.Repository.JitterDuration()
+	if err != nil {
+		global.Sugar.Errorw("parse jitter",
+			"status", "fail",
+			"jitter", global.Conf.Repository.Spec.Jitter,
+			"err", err.Error())
+		return
+	}
+	detection, err := global.Conf.Repository.DetectionSchedule()
+	if err != nil {
+		global.Sugar.Errorw("parse detection schedule",
+			"status", "fail",
+			"err", err.Error())
+		return
+	}
+
+	// 同步检测是一个特殊的任务，会检测两次 pr 之间所有 merged pr 涉及的文件，并提示
+	if global.Conf.Repository.Spec.Workspace.Detection.Enable {
+		addSchedule("detection", detection, func() {
+			SyncIssues()
+		})
+	}
+	for name, job := range global.Jobs {
+		spec := job.Spec.Schedule
+		if spec == "" {
+			spec = detection
+		}
+		job := job
+		addSchedule("job/"+name, spec, func() {
+			Job(job, job.Spec.DryRun)
+		})
+	}
+
+	scheduleMutex.Lock()
+	defer scheduleMutex.Unlock()
+	for _, s := range schedules {
+		go s.start(jitter)
+	}
+}
+
+// Schedules 返回所有定时任务及其下次执行时间，按名称排序
+func Schedules() []Schedule {
+	scheduleMutex.Lock()
+	defer scheduleMutex.Unlock()
+
+	list := make([]Schedule, 0, len(schedules))
+	for _, s := range schedules {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+func addSchedule(name, spec string, run func()) {
+	schedule, err := config.ParseSchedule(spec)
+	if err != nil {
+		global.Sugar.Errorw("parse schedule",
+			"status", "fail",
+			"name", name,
+			"spec", spec,
+			"err", err.Error())
+		return
+	}
+	lastRun, err := store.GetLastRun(global.Store, name)
+	if err != nil {
+		global.Sugar.Errorw("get last run",
+			"name", name,
+			"err", err.Error())
+	}
+
+	scheduleMutex.Lock()
+	defer scheduleMutex.Unlock()
+	schedules[name] = &Schedule{
+		Name:     name,
+		Spec:     spec,
+		Timezone: global.Location.String(),
+		LastRun:  lastRun,
+		schedule: schedule,
+		run:      run,
+	}
+}
+
+func (s *Schedule) start(jitter time.Duration) {
+	// 错过的执行，立即补执行一次
+	// 从未执行过的任务，不视为错过
+	scheduleMutex.Lock()
+	lastRun := s.LastRun
+	scheduleMutex.Unlock()
+	if !lastRun.IsZero() && !s.schedule.Next(lastRun.In(global.Location)).After(time.Now()) {
+		global.Sugar.Infow("catch up missed schedule",
+			"name", s.Name,
+			"last run", lastRun.String())
+		s.exec()
+	}
+
+	for {
+		next := s.schedule.Next(time.Now().In(global.Location))
+		if jitter > 0 {
+			next = next.Add(time.Duration(rand.Int63n(int64(jitter))))
+		}
+		scheduleMutex.Lock()
+		s.Next = next
+		scheduleMutex.Unlock()
+
+		global.Sugar.Infow("waiting for schedule",
+			"name", s.Name,
+			"next", next.String(),
+			"sleep", time.Until(next).String())
+		time.Sleep(time.Until(next))
+		s.exec()
+	}
+}
+
+// 执行任务，并记录执行时间
+func (s *Schedule) exec() {
+	runMutex.Lock()
+	defer runMutex.Unlock()
+
+	s.run()
+
+	now := time.Now()
+	if err := store.PutLastRun(global.Store, s.Name, now); err != nil {
+		global.Sugar.Errorw("save last run",
+			"name", s.Name,
+			"err", err.Error())
+	}
+	scheduleMutex.Lock()
+	s.LastRun = now
+	scheduleMutex.Unlock()
+}
@@ -2,9 +2,13 @@ package operation
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/google/go-github/v30/github"
 	"issue-man/comm"
+	"issue-man/config"
 	"issue-man/global"
+	"issue-man/store"
 	"issue-man/tools"
 	"net/http"
 	"sync"
@@ -17,86 +21,76 @@ var (
 	lock sync.Mutex
 )
 
-// Sync
-// 目前主要完成状态持续时间的检测，并提醒
-// 思路：对于需要检测的状态（label），会将其添加至相应的切片
-//      每天定时检测，满足相关条件时，则执行一些操作
-//
-// Sync 用于定时自动调用同步检测函数
-// 1. 获取所有特定 label 的 issue
-// 2. 获取存储 commit 的 issue
-// 3. 遍历 commit，存储到栈内，直至第二步匹配的 commit。
-// 4. pop commit 栈，分析涉及的文件，是否存在匹配的 issue
-// 5. 对匹配的 issue，comment 提示，该 issue 对应的某个文件在哪次 commit 有变动
-func Sync() {
-	global.Sugar.Info("loaded jobs", "list", global.Jobs)
-	// 解析检测时间
-	t, err := time.ParseInLocation("2006-01-02 15:04",
-		time.Now().Format("2006-01-02 ")+global.Conf.Repository.Spec.Workspace.Detection.At,
-		time.Local)
-	if err != nil {
-		global.Sugar.Errorw("parse detection time",
-			"status", "fail")
-		return
-	}
-
-	// 首次检测等待时间
-	var s time.Duration
-	// 今天已过，则等明天的这个时刻
-	if t.Unix() <= time.Now().Unix() {
-		s = t.AddDate(0, 0, 1).Sub(time.Now())
-	} else {
-		// 否则，等待今天的这个时刻
-		s = t.Sub(time.Now())
-	}
-	global.Sugar.Info("waiting for to detection",
-		"sleep", s.String())
-	time.Sleep(s)
-
-	for {
-		// 同步检测是一个特殊的任务，会检测两次 pr 之间所有 merged pr 涉及的文件，并提示
-		SyncIssues()
-
-		// 遍历检测任务
-		//for _, v := range global.Jobs {
-		//operation.Job(conf.FullRepositoryName, v)
-		//}
-
-		// 等待明天的这个时刻
-		t = t.AddDate(0, 0, 1)
-		s = t.Sub(time.Now())
-		global.Sugar.Info("waiting for to detection",
-			"sleep", s.String())
-		time.Sleep(s)
-	}
+// 一次检测中的处理单元，即一个 pr 或一个 commit
+// 单元内的文件全部处理成功后，才会推进检测的进度
+type syncUnit struct {
+	// 处理完该单元之后的进度
+	cursor store.Cursor
+	files  []comm.File
 }
 
 // SyncIssues 同步检测 issue
-func SyncIssues() {
+// 依次检测 source.branch 及额外配置的分支，返回每个分支的检测结果
+func SyncIssues() []store.SyncReport {
+	reports := make([]store.SyncReport, 0)
 	// 不检查同步 issue
 	if !global.Conf.Repository.Spec.Workspace.Detection.Enable {
-		return
+		return reports
 	}
 	// SyncIssues 可以通过多种方式触发
 	// 这里加一个锁，以避免重复检测提示的情况
 	lock.Lock()
 	defer lock.Unlock()
 
-	// 获取 pr issue
-	prIssue := tools.Issue.GetPRIssue()
-	if prIssue == nil {
-		return
+	for _, branch := range global.Conf.Repository.DetectionBranches() {
+		reports = append(reports, syncBranch(branch))
+	}
+	if err := store.PutSyncReports(global.Store, reports); err != nil {
+		global.Sugar.Errorw("save sync report",
+			"status", "fail",
+			"err", err.Error(),
+		)
 	}
+	return reports
+}
 
-	// 获取 pr 列表
-	prs := tools.PR.ListRangePRs(tools.Parse.PRNumberFromBody(prIssue.GetBody()))
-	if len(prs) == 0 {
-		global.Sugar.Infow("list range merged pull requests", "status", "nothing to do")
+// 检测某个分支
+// 按合并顺序依次处理每个 pr（或 commit），每处理完一个就保存一次进度
+// 某个 pr 中有文件处理失败时，记录失败的文件并停止，下次检测时从该 pr 重试
+func syncBranch(branch string) (report store.SyncReport) {
+	report = store.SyncReport{
+		Branch:    branch,
+		Mode:      global.Conf.Repository.DetectionMode(),
+		StartedAt: time.Now(),
+		Units:     make([]store.SyncUnitReport, 0),
+	}
+	defer func() {
+		report.FinishedAt = time.Now()
+	}()
+
+	// 获取上次检测的进度
+	cursor, prIssue, err := loadCursor(branch)
+	if err != nil {
+		report.Error = err.Error()
 		return
 	}
+	report.From, report.To = cursor, cursor
 
-	// 获取每个 pr 涉及的文件列表
-	files := getAssociatedFiles(prs)
+	// 获取上次检测之后，上游的 pr 或 commit 及其改动的文件列表
+	var units []syncUnit
+	switch report.Mode {
+	case config.DetectionCommit:
+		units, err = commitRangeUnits(branch, cursor)
+	default:
+		units, err = prRangeUnits(branch, cursor)
+	}
+	if err != nil {
+		report.Error = err.Error()
+		return
+	}
+	if len(units) == 0 {
+		return
+	}
 
 	// 获取现有 issue 列表
 	existIssues, err := tools.Issue.GetAllMath()
@@ -105,98 +99,505 @@ func SyncIssues() {
 			"status", "fail",
 			"err", err.Error(),
 		)
+		report.Error = err.Error()
 		return
 	}
 
-	// 更新 pr issue
-	// TODO 保存进度？单独 sync 指定的 pr number
-	defer func() {
-		_, _ = tools.Issue.Edit(prIssue)
-	}()
-	// 最近一次 pr，如果中途失败，需要再次生成 body，以保存进度
-	latestPR := prs[len(prs)-1]
-	prIssue.Body = tools.Generate.BodyByPRNumberAndSha(latestPR.GetNumber(), latestPR.GetMergeCommitSHA())
-
-	wg := sync.WaitGroup{}
-	// 遍历文件
-	// 判断是否匹配
-	// 做出不同操作
-	lock := make(chan int, 5)
-	defer close(lock)
-	go func() {
-		// 将 API 频率限制为每秒 2 次
-		for range lock {
-			time.Sleep(time.Millisecond * 500)
+	// 将 API 频率限制为每秒 2 次
+	limiter := time.NewTicker(time.Millisecond * 500)
+	defer limiter.Stop()
+
+	// 本次检测中的文件变动，汇总后每个 issue 只 comment 一次
+	// 从相同的进度再次同步时，修改之前的 comment
+	digest := comm.NewDigest(branch, cursor.SHA)
+	defer digest.Flush()
+
+	for _, u := range units {
+		unitReport := syncFiles(u, existIssues, limiter.C, syncOptions{record: true, digest: digest})
+		report.Units = append(report.Units, unitReport)
+		if unitReport.Result == store.SyncFailed {
+			break
 		}
-	}()
-	for _, file := range files {
-		wg.Add(1)
-		go func(file comm.File) {
-			defer wg.Done()
-			// 1. 判断是否需要处理
-			include, ok := global.Conf.IssueCreate.SupportFile(file.CommitFile.GetFilename())
-			if ok {
-				global.Sugar.Debugw("get match file",
-					"file name", file.CommitFile.GetFilename(),
-					"match include", include,
-				)
-				lock <- 1
-				file.Sync(
-					include,
-					existIssues[*tools.Generate.Title(file.CommitFile.GetFilename(), include)],
-					existIssues[*tools.Generate.Title(file.CommitFile.GetPreviousFilename(), include)],
-				)
+		// 保存进度，已被进度覆盖的 pr 或 commit，不再需要记录
+		report.To = u.cursor
+		saveCursor(branch, report.To, prIssue)
+		if err := store.DeleteSyncProgress(global.Store, u.cursor.SHA); err != nil {
+			global.Sugar.Errorw("delete sync progress",
+				"status", "fail",
+				"sha", u.cursor.SHA,
+				"err", err.Error(),
+			)
+		}
+	}
+	for _, u := range units[len(report.Units):] {
+		report.Units = append(report.Units, store.SyncUnitReport{
+			PRNumber: u.cursor.PRNumber,
+			SHA:      u.cursor.SHA,
+			Result:   store.SyncPending,
+			Files:    make([]store.SyncFileReport, 0),
+		})
+	}
+
+	return
+}
+
+// 处理文件的方式
+type syncOptions struct {
+	// 是否记录处理进度，手动同步时不记录，也不跳过之前已处理的文件
+	record bool
+	// 为 true 时，只返回将要做出的改动
+	dryRun bool
+	// 不为 nil 时，文件变动汇总到 digest 中，不单独 comment
+	digest *comm.Digest
+}
+
+// 处理一个 pr 或 commit 中的文件
+// 跳过之前已处理成功的文件，并记录本次的处理结果
+func syncFiles(u syncUnit, existIssues map[string]*github.Issue, tick <-chan time.Time, opt syncOptions) store.SyncUnitReport {
+	unitReport := store.SyncUnitReport{
+		PRNumber: u.cursor.PRNumber,
+		SHA:      u.cursor.SHA,
+		Result:   store.SyncApplied,
+		Files:    make([]store.SyncFileReport, 0, len(u.files)),
+	}
+	if opt.dryRun {
+		unitReport.Result = store.SyncPlanned
+	}
+	progress := store.SyncProgress{}
+	if opt.record {
+		var err error
+		progress, _, err = store.GetSyncProgress(global.Store, u.cursor.SHA)
+		if err != nil {
+			global.Sugar.Errorw("load sync progress",
+				"status", "fail",
+				"sha", u.cursor.SHA,
+				"err", err.Error(),
+			)
+		}
+	}
+	if progress.Done {
+		unitReport.Result = store.SyncSkipped
+		return unitReport
+	}
+	progress.SHA = u.cursor.SHA
+	progress.PRNumber = u.cursor.PRNumber
+	progress.Failed = make(map[string]string)
+	applied := tools.Convert.StringToMap(progress.Applied)
+
+	for _, file := range u.files {
+		fileReport := store.SyncFileReport{
+			Filename:         file.CommitFile.GetFilename(),
+			PreviousFilename: file.CommitFile.GetPreviousFilename(),
+			Status:           file.CommitFile.GetStatus(),
+			Result:           store.SyncSkipped,
+		}
+		// 1. 判断是否需要处理
+		include, ok := global.Conf.IssueCreate.SupportFile(file.CommitFile.GetFilename())
+		if !ok || applied[fileReport.Filename] {
+			unitReport.Files = append(unitReport.Files, fileReport)
+			continue
+		}
+		global.Sugar.Debugw("get match file",
+			"file name", file.CommitFile.GetFilename(),
+			"match include", include,
+		)
+		title := comm.IndexedTitle(existIssues, file.CommitFile.GetFilename(), *tools.Generate.Title(file.CommitFile.GetFilename(), include))
+		existIssue := existIssues[title]
+		// 先判断改动是否可以忽略，可以忽略的改动只记录在原 issue 中，即使该 issue 已关闭
+		if existIssue != nil {
+			fileReport.Ignored = file.Ignored()
+		}
+		// 对应的 issue 已关闭时，按 detection.closed 处理
+		if file.CommitFile.GetStatus() != comm.REMOVE && fileReport.Ignored == "" {
+			var ok bool
+			existIssue, file.Translated, ok = comm.Resolve(existIssues, title)
+			if !ok {
+				fileReport.Ignored = config.IgnoreClosed
+				unitReport.Files = append(unitReport.Files, fileReport)
+				continue
 			}
-		}(file)
+		}
+		preFilename := file.CommitFile.GetPreviousFilename()
+		preIssue := existIssues[comm.IndexedTitle(existIssues, preFilename, *tools.Generate.Title(preFilename, include))]
+		if opt.dryRun {
+			fileReport.Result = store.SyncPlanned
+			fileReport.Plan = file.Plan(include, existIssue, preIssue)
+			// 后续的文件视为已创建该 issue
+			if existIssue == nil && file.CommitFile.GetStatus() != comm.REMOVE {
+				created := title
+				if file.Translated != nil {
+					created = comm.UpdateTitle(title)
+				}
+				existIssues[created] = &github.Issue{Title: &created}
+			}
+			unitReport.Files = append(unitReport.Files, fileReport)
+			continue
+		}
+		<-tick
+		file.Digest = opt.digest
+		issues, err := file.Sync(include, existIssue, preIssue)
+		// 后续的文件需要基于最新的 issue 内容处理
+		for _, v := range issues {
+			existIssues[v.GetTitle()] = v
+			fileReport.Issues = append(fileReport.Issues, v.GetNumber())
+		}
+		if err != nil {
+			fileReport.Result = store.SyncFailed
+			fileReport.Error = err.Error()
+			progress.Failed[fileReport.Filename] = err.Error()
+			unitReport.Result = store.SyncFailed
+		} else {
+			fileReport.Result = store.SyncApplied
+			progress.Applied = append(progress.Applied, fileReport.Filename)
+		}
+		unitReport.Files = append(unitReport.Files, fileReport)
 	}
-	wg.Wait()
+
+	if !opt.record || opt.dryRun {
+		return unitReport
+	}
+	progress.Done = unitReport.Result != store.SyncFailed
+	progress.Attempts++
+	progress.UpdatedAt = time.Now()
+	if err := store.PutSyncProgress(global.Store, progress); err != nil {
+		global.Sugar.Errorw("save sync progress",
+			"status", "fail",
+			"sha", u.cursor.SHA,
+			"err", err.Error(),
+		)
+	}
+	return unitReport
 }
 
-func getAssociatedFiles(prs []*github.PullRequest) []comm.File {
-	files := make([]comm.File, 0)
+// 检测进度在存储中的 key
+// source.branch 沿用旧版本的 key，其它分支以分支名区分
+func cursorKey(branch string) string {
+	if branch == global.Conf.Repository.Spec.Source.Branch {
+		return store.DetectionCursor
+	}
+	return store.DetectionCursor + "/" + branch
+}
+
+// 获取上次检测的进度
+// 优先从存储中读取，存储中没有时（例如从旧版本升级），从 pr issue 的 body 中解析
+// checkpoint 为 issue 时，以 pr issue 的 body 为准
+// pr issue 仅记录 source.branch 的进度，其它分支第一次检测时，从分支最新的 pr 或 commit 开始
+func loadCursor(branch string) (cursor store.Cursor, prIssue *github.Issue, err error) {
+	repository := global.Conf.Repository
+	primary := branch == repository.Spec.Source.Branch
+	if primary && repository.Spec.Workspace.Detection.PRIssue != 0 {
+		prIssue = tools.Issue.GetPRIssue()
+		if prIssue == nil {
+			return cursor, nil, fmt.Errorf("load pr issue %d fail", repository.Spec.Workspace.Detection.PRIssue)
+		}
+	}
+
+	if !primary || repository.DetectionCheckpoint() != config.CheckpointIssue {
+		c, found, err := store.GetCursor(global.Store, cursorKey(branch))
+		if err != nil {
+			global.Sugar.Errorw("load detection cursor",
+				"status", "fail",
+				"err", err.Error(),
+			)
+			return cursor, nil, err
+		}
+		if found {
+			return c, prIssue, nil
+		}
+	}
+
+	if prIssue == nil {
+		if primary {
+			global.Sugar.Errorw("load detection cursor",
+				"status", "fail",
+				"err", "no cursor in store and detection.prIssue is not set",
+			)
+			return cursor, nil, fmt.Errorf("no detection cursor of branch %s", branch)
+		}
+		cursor = InitCursor(branch)
+		if cursor.SHA == "" {
+			return cursor, nil, fmt.Errorf("no merged pr or commit in branch %s", branch)
+		}
+		return cursor, nil, nil
+	}
+	return cursorFromBody(prIssue.GetBody()), prIssue, nil
+}
+
+// InitCursor 将分支的检测进度设置为分支当前的进度，并返回该进度
+// 即从现在开始检测，不处理之前的 pr 或 commit
+func InitCursor(branch string) store.Cursor {
+	cursor := latestCursor(branch)
+	var prIssue *github.Issue
+	if branch == global.Conf.Repository.Spec.Source.Branch && global.Conf.Repository.Spec.Workspace.Detection.PRIssue != 0 {
+		prIssue = tools.Issue.GetPRIssue()
+	}
+	saveCursor(branch, cursor, prIssue)
+	global.Sugar.Infow("init detection cursor",
+		"branch", branch,
+		"cursor", cursor)
+	return cursor
+}
+
+// 分支当前的进度，即最近一次 merged 的 pr
+// 按 commit 范围检测时，则为分支最新的 commit
+func latestCursor(branch string) store.Cursor {
+	cursor := store.Cursor{}
+	if latestPR := tools.PR.LatestMerged(branch); latestPR != nil {
+		cursor = store.Cursor{
+			PRNumber: latestPR.GetNumber(),
+			SHA:      latestPR.GetMergeCommitSHA(),
+			MergedAt: latestPR.GetMergedAt(),
+		}
+	}
+	if global.Conf.Repository.DetectionMode() == config.DetectionCommit {
+		if head, err := tools.Commit.Head(branch); err == nil {
+			cursor.SHA = head
+		}
+	}
+	return cursor
+}
+
+// 保存检测的进度，并更新 pr issue 的 body
+func saveCursor(branch string, cursor store.Cursor, prIssue *github.Issue) {
+	cursor.UpdatedAt = time.Now()
+	if err := store.PutCursor(global.Store, cursorKey(branch), cursor); err != nil {
+		global.Sugar.Errorw("save detection cursor",
+			"status", "fail",
+			"cursor", cursor,
+			"err", err.Error(),
+		)
+	}
+	if prIssue != nil {
+		// store.backend 为 issue 时，数据可能保存在 pr issue 中，需要保留最新的数据
+		current := prIssue.GetBody()
+		if latest, err := tools.Issue.Get(prIssue.GetNumber()); err == nil {
+			current = latest.GetBody()
+		}
+		body := store.WithIssueSection(*tools.Generate.BodyByPRNumberAndSha(cursor.PRNumber, cursor.SHA), store.IssueSection(current))
+		prIssue.Body = &body
+		_, _ = tools.Issue.Edit(prIssue)
+	}
+}
 
+// 从 pr issue 的 body 中解析检测的进度
+func cursorFromBody(body string) store.Cursor {
+	body = store.WithoutIssueSection(body)
+	return store.Cursor{
+		PRNumber: tools.Parse.PRNumberFromBody(body),
+		SHA:      tools.Parse.SHAFromBody(body),
+	}
+}
+
+// 按 merged pr 检测
+// 获取上次处理的 pr 之后合并至 branch 的 pr 及其涉及的文件，按合并时间排序
+// 与上次处理的 pr 同时合并的 pr，根据 merge commit 是否已包含在上次处理的 commit 中判断是否需要处理
+func prRangeUnits(branch string, cursor store.Cursor) ([]syncUnit, error) {
+	cursor, err := upgradeCursor(cursor, tools.PR.Get)
+	if err != nil {
+		return nil, err
+	}
+	if cursor.MergedAt.IsZero() {
+		return nil, fmt.Errorf("no merged pr in detection cursor of branch %s", branch)
+	}
+
+	// 获取失败时返回错误，记录至检测结果，检测进度保持不变
+	prs, err := tools.PR.ListRangePRs(branch, cursor.MergedAt)
+	if err != nil {
+		return nil, err
+	}
+	prs, err = pendingPRs(prs, cursor, tools.Commit.Ancestor)
+	if err != nil {
+		return nil, err
+	}
+	if len(prs) == 0 {
+		global.Sugar.Infow("list range merged pull requests", "branch", branch, "status", "nothing to do")
+		return nil, nil
+	}
+
+	// 获取每个 pr 涉及的文件列表
+	units := make([]syncUnit, 0, len(prs))
 	for _, v := range prs {
-		for {
-			opt := &github.ListOptions{
-				Page:    1,
-				PerPage: 3000,
-			}
-			tmp, resp, err := global.Client.PullRequests.ListFiles(
-				context.TODO(),
-				global.Conf.Repository.Spec.Source.Owner,
-				global.Conf.Repository.Spec.Source.Repository,
-				v.GetNumber(),
-				opt)
+		files, err := getAssociatedFiles(v)
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, syncUnit{
+			cursor: store.Cursor{PRNumber: v.GetNumber(), SHA: v.GetMergeCommitSHA(), MergedAt: v.GetMergedAt()},
+			files:  files,
+		})
+	}
+	return units, nil
+}
+
+// 旧版本的进度只记录了 pr number，通过 get 获取该 pr 的合并时间和 merge commit
+func upgradeCursor(cursor store.Cursor, get func(number int) (*github.PullRequest, error)) (store.Cursor, error) {
+	if !cursor.MergedAt.IsZero() || cursor.PRNumber == 0 {
+		return cursor, nil
+	}
+	pr, err := get(cursor.PRNumber)
+	if err != nil {
+		return cursor, err
+	}
+	cursor.MergedAt = pr.GetMergedAt()
+	if cursor.SHA == "" {
+		cursor.SHA = pr.GetMergeCommitSHA()
+	}
+	return cursor, nil
+}
+
+// 从 cursor.MergedAt 之后（包括）合并的 pr 中，去掉已经处理过的 pr
+// 与 cursor 合并时间相同的 pr，通过 ancestor 判断其 merge commit 是否已包含在 cursor.SHA 中
+func pendingPRs(prs []*github.PullRequest, cursor store.Cursor, ancestor func(sha, base string) (bool, error)) ([]*github.PullRequest, error) {
+	pending := make([]*github.PullRequest, 0, len(prs))
+	for _, v := range prs {
+		if v.GetMergeCommitSHA() == cursor.SHA || v.GetNumber() == cursor.PRNumber {
+			continue
+		}
+		if v.GetMergedAt().Equal(cursor.MergedAt) && cursor.SHA != "" {
+			processed, err := ancestor(v.GetMergeCommitSHA(), cursor.SHA)
 			if err != nil {
-				global.Sugar.Errorw("load pr file list",
-					"call api", "failed",
-					"err", err.Error(),
-				)
-				return nil
-			}
-			if resp.StatusCode != http.StatusOK {
-				global.Sugar.Errorw("load pr file list",
-					"call api", "unexpect status code",
-					"status", resp.Status,
-					"status code", resp.StatusCode,
-					"response", resp.Body,
-				)
-				return nil
-			}
-			for _, cf := range tmp {
-				files = append(files, comm.File{
-					PrNumber:       v.GetNumber(),
-					MergedAt:       v.GetMergedAt().In(time.Local).String(),
-					MergeCommitSHA: v.GetMergeCommitSHA(),
-					CommitFile:     cf,
-				})
+				return nil, err
 			}
-			// 结束内层循环
-			if len(tmp) < opt.PerPage {
-				break
+			if processed {
+				continue
 			}
-			opt.Page++
 		}
+		pending = append(pending, v)
+	}
+	return pending, nil
+}
+
+// 按 commit 范围检测
+// 比较上次处理的 commit 与分支最新的 commit，获取其间每个 commit 涉及的文件
+// 包括直接 push、cherry-pick 等不经过 pr 的改动，能找到对应的 pr 时，附带 pr 的信息
+func commitRangeUnits(branch string, cursor store.Cursor) ([]syncUnit, error) {
+	base := cursor.SHA
+	if base == "" {
+		global.Sugar.Errorw("load commit sha",
+			"status", "fail",
+			"cursor", cursor,
+		)
+		return nil, fmt.Errorf("no commit sha in detection cursor")
+	}
+	units, err := commitUnits(base, branch, cursor.PRNumber)
+	if !errors.Is(err, tools.ErrCommitNotFound) {
+		return units, err
+	}
+	// 分支存在而上次处理的 commit 已不存在（如 force push），之后的检测都会失败
+	// 将进度重新设置为分支最新的 commit，其间的改动需要通过 sync --from 手动同步
+	if _, headErr := tools.Commit.Head(branch); headErr != nil {
+		return nil, err
+	}
+	global.Sugar.Errorw("compare detection cursor",
+		"status", "commit not found, reinit cursor",
+		"branch", branch,
+		"cursor", cursor,
+	)
+	latest := InitCursor(branch)
+	return nil, fmt.Errorf("commit %s in detection cursor of branch %s not found, maybe force pushed, cursor has been reset to %s, use sync --from to replay the skipped changes", base, branch, latest.SHA)
+}
+
+// 获取 base 之后到 head 之间每个 commit 涉及的文件
+// prNumber 为 base 对应的 pr，没有对应 pr 的 commit 沿用之前的 pr number，以便切换回 pr 方式检测
+func commitUnits(base, head string, prNumber int) ([]syncUnit, error) {
+	commits, total, err := tools.Commit.Compare(base, head)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		global.Sugar.Infow("compare commits", "status", "nothing to do")
+		return nil, nil
+	}
+	if total > len(commits) {
+		global.Sugar.Warnw("compare commits",
+			"status", "too many commits, the rest will be processed next time",
+			"total", total,
+			"len", len(commits),
+		)
+	}
+
+	units := make([]syncUnit, 0, len(commits))
+	for _, v := range commits {
+		// merge commit 的改动已包含在其 parent commit 中，无需重复处理
+		if len(v.Parents) > 1 {
+			units = append(units, syncUnit{cursor: store.Cursor{PRNumber: prNumber, SHA: v.GetSHA(), MergedAt: v.GetCommit().GetCommitter().GetDate()}})
+			continue
+		}
+		// 获取失败时返回错误，不处理任何 commit，检测进度保持不变，下次检测时重试
+		commit, err := tools.Commit.Get(v.GetSHA())
+		if err != nil {
+			global.Sugar.Errorw("load commit files",
+				"status", "fail",
+				"sha", v.GetSHA(),
+				"err", err.Error(),
+			)
+			return nil, fmt.Errorf("load commit %s: %w", v.GetSHA(), err)
+		}
+		number := 0
+		if pr := tools.Commit.PullRequest(v.GetSHA()); pr != nil {
+			number = pr.GetNumber()
+			prNumber = number
+		}
+		date := commit.GetCommit().GetCommitter().GetDate()
+		u := syncUnit{cursor: store.Cursor{PRNumber: prNumber, SHA: v.GetSHA(), MergedAt: date}}
+		for _, cf := range commit.Files {
+			u.files = append(u.files, comm.File{
+				PrNumber:       number,
+				MergedAt:       date.In(global.Location).String(),
+				MergeCommitSHA: v.GetSHA(),
+				CommitFile:     cf,
+				Message:        commit.GetCommit().GetMessage(),
+			})
+		}
+		units = append(units, u)
+	}
+	return units, nil
+}
+
+// 获取 pr 涉及的文件列表
+func getAssociatedFiles(v *github.PullRequest) ([]comm.File, error) {
+	files := make([]comm.File, 0)
+	opt := &github.ListOptions{
+		Page:    1,
+		PerPage: 100,
+	}
+	for {
+		tmp, resp, err := global.Client.PullRequests.ListFiles(
+			context.TODO(),
+			global.Conf.Repository.Spec.Source.Owner,
+			global.Conf.Repository.Spec.Source.Repository,
+			v.GetNumber(),
+			opt)
+		if err != nil {
+			global.Sugar.Errorw("load pr file list",
+				"call api", "failed",
+				"err", err.Error(),
+			)
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			global.Sugar.Errorw("load pr file list",
+				"call api", "unexpect status code",
+				"status", resp.Status,
+				"status code", resp.StatusCode,
+				"response", resp.Body,
+			)
+			return nil, fmt.Errorf("load pr file list fail. status code:%d", resp.StatusCode)
+		}
+		for _, cf := range tmp {
+			files = append(files, comm.File{
+				PrNumber:       v.GetNumber(),
+				MergedAt:       v.GetMergedAt().In(global.Location).String(),
+				MergeCommitSHA: v.GetMergeCommitSHA(),
+				CommitFile:     cf,
+				Message:        v.GetTitle(),
+			})
+		}
+		// 结束循环
+		if len(tmp) < opt.PerPage {
+			break
+		}
+		opt.Page++
 	}
-	return files
+	return files, nil
 }
@@ -0,0 +1,92 @@
+package operation
+
+import (
+	"errors"
+	"github.com/google/go-github/v30/github"
+	"issue-man/store"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testPR(number int, sha string, merged time.Time) *github.PullRequest {
+	return &github.PullRequest{Number: &number, MergeCommitSHA: &sha, MergedAt: &merged}
+}
+
+func Test_pendingPRs(t *testing.T) {
+	at := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	prs := []*github.PullRequest{
+		testPR(10, "a", at),
+		testPR(11, "b", at),
+		testPR(12, "c", at),
+		testPR(13, "d", at.Add(time.Hour)),
+	}
+	// b 已包含在 a 中，c 在 a 之后合并
+	ancestor := func(sha, base string) (bool, error) {
+		return sha == "b" && base == "a", nil
+	}
+
+	tests := []struct {
+		name     string
+		cursor   store.Cursor
+		ancestor func(sha, base string) (bool, error)
+		want     []int
+		wantErr  bool
+	}{
+		{name: "skip cursor and ancestors", cursor: store.Cursor{PRNumber: 10, SHA: "a", MergedAt: at}, ancestor: ancestor, want: []int{12, 13}},
+		{name: "legacy cursor without sha", cursor: store.Cursor{PRNumber: 10, MergedAt: at}, ancestor: ancestor, want: []int{11, 12, 13}},
+		{name: "ancestor error", cursor: store.Cursor{PRNumber: 10, SHA: "a", MergedAt: at}, ancestor: func(string, string) (bool, error) { return false, errors.New("compare") }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending, err := pendingPRs(prs, tt.cursor, tt.ancestor)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pendingPRs() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := make([]int, 0, len(pending))
+			for _, v := range pending {
+				got = append(got, v.GetNumber())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pendingPRs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_upgradeCursor(t *testing.T) {
+	at := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	get := func(number int) (*github.PullRequest, error) {
+		if number != 10 {
+			return nil, errors.New("not found")
+		}
+		return testPR(10, "a", at), nil
+	}
+
+	tests := []struct {
+		name    string
+		cursor  store.Cursor
+		want    store.Cursor
+		wantErr bool
+	}{
+		{name: "legacy pr number", cursor: store.Cursor{PRNumber: 10}, want: store.Cursor{PRNumber: 10, SHA: "a", MergedAt: at}},
+		{name: "keep sha", cursor: store.Cursor{PRNumber: 10, SHA: "x"}, want: store.Cursor{PRNumber: 10, SHA: "x", MergedAt: at}},
+		{name: "already upgraded", cursor: store.Cursor{PRNumber: 11, SHA: "b", MergedAt: at}, want: store.Cursor{PRNumber: 11, SHA: "b", MergedAt: at}},
+		{name: "empty", cursor: store.Cursor{}, want: store.Cursor{}},
+		{name: "get error", cursor: store.Cursor{PRNumber: 11}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := upgradeCursor(tt.cursor, get)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("upgradeCursor() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("upgradeCursor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -30,6 +30,9 @@ func Destroy(conf config.Config) {
 		}
 	}()
 	for _, v := range issues {
+		if v.GetState() != "open" {
+			continue
+		}
 		wg.Add(1)
 		go func(issue *github.Issue) {
 			defer wg.Done()
@@ -4,8 +4,10 @@ package server
 
 import (
 	"github.com/google/go-github/v30/github"
+	"issue-man/comm"
 	"issue-man/config"
 	"issue-man/global"
+	"issue-man/operation"
 	"issue-man/tools"
 	"sync"
 	"time"
@@ -28,13 +30,14 @@ func Init(conf config.Config) {
 	// 默认情况下，基于配置的分支内容来创建 issue
 	sha := global.Conf.Repository.Spec.Source.Branch
 	// 在启用检测同步 issue时，则需要
-	// 获取最近一个 merged pr 的信息，并将其保存至 pr issue
+	// 获取各个分支最近一个 merged pr 的信息，并将其保存至存储和 pr issue
 	if global.Conf.Repository.Spec.Workspace.Detection.Enable {
-		prIssue := tools.Issue.GetPRIssue()
-		latestPR := tools.PR.LatestMerged()
-		prIssue.Body = tools.Generate.BodyByPRNumberAndSha(latestPR.GetNumber(), latestPR.GetMergeCommitSHA())
-		defer tools.Issue.Edit(prIssue)
-		sha = latestPR.GetMergeCommitSHA()
+		for _, branch := range global.Conf.Repository.DetectionBranches() {
+			cursor := operation.InitCursor(branch)
+			if branch == global.Conf.Repository.Spec.Source.Branch && cursor.SHA != "" {
+				sha = cursor.SHA
+			}
+		}
 	}
 
 	genAndCreateIssues(sha)
@@ -80,8 +83,8 @@ func genAndCreateIssues(sha string) {
 		include, ok := global.Conf.IssueCreate.SupportFile(file)
 		// 符合条件的文件
 		if ok {
-			// 根据 title 判断，如果已存在相关 issue，则更新
-			exist := existIssues[*tools.Generate.Title(file, include)]
+			// 根据索引和 title 判断，如果已存在相关 issue，则更新
+			exist := existIssues[comm.IndexedTitle(existIssues, file, *tools.Generate.Title(file, include))]
 			if exist != nil {
 				updates[*exist.Number] = tools.Generate.UpdateIssue(false, file, *exist)
 			} else {
@@ -115,11 +118,12 @@ func genAndCreateIssues(sha string) {
 		go func(number int, issue *github.IssueRequest) {
 			defer wg.Done()
 			lock <- 1
-			_, err := tools.Issue.EditByIssueRequest(number, issue)
+			updatedIssue, err := tools.Issue.EditByIssueRequest(number, issue)
 			if err != nil {
 				updateFail++
 				return
 			}
+			tools.Issue.Index(updatedIssue)
 		}(k, v)
 	}
 	wg.Wait()
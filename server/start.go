@@ -5,12 +5,16 @@
 package server
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"gopkg.in/go-playground/webhooks.v5/github"
+	"io/ioutil"
 	"issue-man/config"
 	"issue-man/global"
 	"issue-man/operation"
+	"issue-man/store"
 	"issue-man/tools"
 	"log"
 	"net/http"
@@ -27,6 +31,9 @@ var (
 )
 
 func Start(conf config.Config) {
+	// 升级存储结构，失败时下次启动再次尝试
+	_ = operation.Migrate()
+
 	// 定时检测任务
 	go operation.Sync()
 
@@ -43,6 +50,10 @@ func Start(conf config.Config) {
 	{
 		v1.GET("/init", check, InitIssue)
 		v1.GET("/sync", check, Sync)
+		v1.GET("/sync/report", check, SyncReport)
+		v1.GET("/job", check, RunJob)
+		v1.GET("/schedules", check, Schedules)
+		v1.GET("/reconcile", check, Reconcile)
 		v1.GET("/load", check, Load)
 		v1.POST("/webhooks/", Webhooks)
 	}
@@ -80,7 +91,27 @@ func check(c *gin.Context) {
 }
 
 // 手动调用更新函数
+// 参数 pr 或 from、to 不为空时，仅同步指定的 pr 或 commit 范围，不修改检测进度
+// dry-run 为 true 时仅返回将要做出的改动
 func Sync(c *gin.Context) {
+	opt := operation.ReplayOptions{
+		From:   c.Query("from"),
+		To:     c.Query("to"),
+		DryRun: c.Query("dry-run") == "true",
+	}
+	if v := c.Query("pr"); v != "" {
+		number, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"status": "fail", "cause": "bad pr number"})
+			return
+		}
+		opt.PR = number
+	}
+	if opt.To != "" && opt.From == "" {
+		c.JSON(http.StatusOK, gin.H{"status": "fail", "cause": "from is required"})
+		return
+	}
+
 	select {
 	case lock <- 1:
 	case <-time.NewTimer(time.Second * 3).C:
@@ -90,8 +121,74 @@ func Sync(c *gin.Context) {
 	defer func() {
 		<-lock
 	}()
-	operation.SyncIssues()
-	c.JSON(http.StatusOK, gin.H{"status": "done"})
+	if opt.PR == 0 && opt.From == "" {
+		c.JSON(http.StatusOK, gin.H{"status": "done", "reports": operation.SyncIssues()})
+		return
+	}
+	report, err := operation.Replay(opt)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"status": "fail", "cause": err.Error(), "report": report})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "done", "dryRun": opt.DryRun, "report": report})
+}
+
+// 获取最近一次同步检测的结果，包括每个 pr 及文件的处理结果
+func SyncReport(c *gin.Context) {
+	reports, ok, err := store.GetSyncReports(global.Store)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"status": "fail", "cause": err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"status": "fail", "cause": "no sync report"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "done", "reports": reports})
+}
+
+// 手动执行某个 job
+// 参数 name 为 job 名，dry-run 为 true 时仅返回将要做出的改动
+func RunJob(c *gin.Context) {
+	job, ok := global.Jobs[c.Query("name")]
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"status": "fail", "cause": "unknown job"})
+		return
+	}
+	dryRun := job.Spec.DryRun || c.Query("dry-run") == "true"
+
+	select {
+	case lock <- 1:
+	case <-time.NewTimer(time.Second * 3).C:
+		c.JSON(http.StatusOK, gin.H{"status": "fail", "cause": "other task in progressing"})
+		return
+	}
+	defer func() {
+		<-lock
+	}()
+	c.JSON(http.StatusOK, gin.H{"status": "done", "dryRun": dryRun, "results": operation.Job(job, dryRun)})
+}
+
+// 修复违反互斥规则的 label
+// 参数 dry-run 为 true 时仅返回将要做出的改动
+func Reconcile(c *gin.Context) {
+	dryRun := c.Query("dry-run") == "true"
+
+	select {
+	case lock <- 1:
+	case <-time.NewTimer(time.Second * 3).C:
+		c.JSON(http.StatusOK, gin.H{"status": "fail", "cause": "other task in progressing"})
+		return
+	}
+	defer func() {
+		<-lock
+	}()
+	c.JSON(http.StatusOK, gin.H{"status": "done", "dryRun": dryRun, "results": operation.Reconcile(dryRun)})
+}
+
+// 列出所有定时任务及其下次执行时间
+func Schedules(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "done", "schedules": operation.Schedules()})
 }
 
 // 重新初始化，不会重复创建 issue，可以修复一些文件列表异常的 issue，
@@ -121,6 +218,15 @@ func Webhooks(c *gin.Context) {
 	defer func() {
 		<-lock
 	}()
+	// 保留原始数据，用于解析 payload 中未包含的字段
+	raw, err := c.GetRawData()
+	if err != nil {
+		global.Sugar.Errorw("read payload",
+			"status", "fail",
+			"err", err.Error())
+	}
+	c.Request.Body = ioutil.NopCloser(bytes.NewReader(raw))
+
 	hook, _ := github.New()
 	// 解析的事件列表
 	p, err := hook.Parse(c.Request,
@@ -137,7 +243,7 @@ func Webhooks(c *gin.Context) {
 	}
 	switch p.(type) {
 	case github.IssueCommentPayload:
-		issueComment(p.(github.IssueCommentPayload))
+		issueComment(p.(github.IssueCommentPayload), raw)
 	case github.OrganizationPayload:
 		org(p.(github.OrganizationPayload))
 	case github.MembershipPayload:
@@ -153,17 +259,16 @@ func Webhooks(c *gin.Context) {
 
 // issueComment
 // webhook payload 数据是 issue comment 事件
-func issueComment(payload github.IssueCommentPayload) {
+// 根据事件的 action 分别处理：
+// created：执行评论中的指令
+// edited：根据配置，执行新增的指令，或者忽略
+// deleted：根据配置，撤销评论中的指令做出的改动，或者忽略
+func issueComment(payload github.IssueCommentPayload, raw []byte) {
 	// 只处理 workspace 组织的 comment 事件
 	if payload.Repository.Owner.Login != tools.Get.WorkspaceOwner() {
 		return
 	}
 
-	// 不处理已关闭的 issue
-	if payload.Issue.State == "closed" {
-		return
-	}
-
 	const (
 		TypeIssue = "issues"
 		TypePR    = "pull"
@@ -176,14 +281,43 @@ func issueComment(payload github.IssueCommentPayload) {
 	}
 
 	global.Sugar.Debugw("issue comment payload", "data", payload)
-	is := tools.Parse.Instruct(payload.Comment.Body)
-	// 未能解析出任何指令
-	if len(is) == 0 {
-		return
-	}
+	switch payload.Action {
+	case "created":
+		// 不处理已关闭的 issue
+		if payload.Issue.State == "closed" {
+			return
+		}
+		is := tools.Parse.Instruct(payload.Comment.Body)
+		// 未能解析出任何指令
+		if len(is) == 0 {
+			return
+		}
 
-	// 执行指令
-	operation.IssueHanding(payload, is)
+		// 执行指令
+		operation.IssueHanding(payload, is)
+	case "edited":
+		// 不处理已关闭的 issue
+		if payload.Issue.State == "closed" {
+			return
+		}
+		// 编辑前的内容
+		changes := struct {
+			Changes struct {
+				Body struct {
+					From string `json:"from"`
+				} `json:"body"`
+			} `json:"changes"`
+		}{}
+		if err := json.Unmarshal(raw, &changes); err != nil {
+			global.Sugar.Errorw("unmarshal comment changes",
+				"status", "fail",
+				"err", err.Error())
+			return
+		}
+		operation.CommentEdited(payload, changes.Changes.Body.From)
+	case "deleted":
+		operation.CommentDeleted(payload)
+	}
 }
 
 // 根据 URL 判断 comment 的类型
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"strconv"
+	"time"
+)
+
+const AuditBucket = "audit"
+
+// IssueState 记录了 issue 中会被指令修改的内容
+type IssueState struct {
+	Labels    []string `json:"labels"`
+	Assignees []string `json:"assignees"`
+	State     string   `json:"state"`
+}
+
+// Record 记录了一次指令对 issue 做出的改动
+type Record struct {
+	ReqID       string    `json:"reqId"`
+	CommentID   int64     `json:"commentId"`
+	IssueNumber int       `json:"issueNumber"`
+	Instruct    string    `json:"instruct"`
+	Login       string    `json:"login"`
+	At          time.Time `json:"at"`
+
+	Before IssueState `json:"before"`
+	After  IssueState `json:"after"`
+
+	// 是否已被撤销
+	Undone bool `json:"undone"`
+	// 撤销操作的记录，值为被撤销的改动的 ReqID
+	Reverts string `json:"reverts,omitempty"`
+}
+
+// ListRecords 获取某个 issue 的全部改动记录，按时间正序排列
+func ListRecords(s Store, number int) (records []Record, err error) {
+	records = make([]Record, 0)
+	_, err = s.Get(AuditBucket, strconv.Itoa(number), &records)
+	return
+}
+
+// PutRecords 覆盖保存某个 issue 的全部改动记录
+func PutRecords(s Store, number int, records []Record) error {
+	return s.Put(AuditBucket, strconv.Itoa(number), records)
+}
+
+// AppendRecord 追加一条改动记录
+func AppendRecord(s Store, r Record) error {
+	records, err := ListRecords(s, r.IssueNumber)
+	if err != nil {
+		return err
+	}
+	return PutRecords(s, r.IssueNumber, append(records, r))
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"encoding/json"
+	bolt "go.etcd.io/bbolt"
+	"time"
+)
+
+// 基于 BoltDB 的存储
+// 每次写入都在一个事务中完成，进程中途退出不会损坏已保存的数据
+type boltStore struct {
+	db *bolt.DB
+}
+
+// OpenBolt 打开一个基于 BoltDB 的存储，文件不存在时会自动创建
+// 同一个文件同时只能被一个进程打开，超时未获取到文件锁时返回错误
+func OpenBolt(path string) (Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) Get(bucket, key string, value interface{}) (ok bool, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		raw := b.Get([]byte(key))
+		if raw == nil {
+			return nil
+		}
+		ok = true
+		return json.Unmarshal(raw, value)
+	})
+	return
+}
+
+func (s *boltStore) Put(bucket, key string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), raw)
+	})
+}
+
+func (s *boltStore) Delete(bucket, key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		return b.Delete([]byte(key))
+	})
+}
+
+// bolt 中的 key 是有序的，无需再排序
+func (s *boltStore) Keys(bucket string) (keys []string, err error) {
+	keys = make([]string, 0)
+	err = s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, _ []byte) error {
+			keys = append(keys, string(k))
+			return nil
+		})
+	})
+	return
+}
+
+func (s *boltStore) Buckets() (buckets []string, err error) {
+	buckets = make([]string, 0)
+	err = s.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			buckets = append(buckets, string(name))
+			return nil
+		})
+	})
+	return
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
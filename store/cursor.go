@@ -0,0 +1,29 @@
+package store
+
+import "time"
+
+const CursorBucket = "cursor"
+
+// DetectionCursor 同步检测进度的 key
+// 源库配置的分支使用该 key，其它额外检测的分支使用 DetectionCursor + "/" + 分支名
+const DetectionCursor = "detection"
+
+// Cursor 记录了同步检测的进度，即最近一次处理的 pr 和 commit
+type Cursor struct {
+	PRNumber int    `json:"prNumber"`
+	SHA      string `json:"sha"`
+	// 最近一次处理的 pr 的合并时间，按 pr 检测时，之后合并的 pr 即需要处理的 pr
+	MergedAt  time.Time `json:"mergedAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// GetCursor 获取某个检测的进度
+func GetCursor(s Store, name string) (c Cursor, ok bool, err error) {
+	ok, err = s.Get(CursorBucket, name, &c)
+	return
+}
+
+// PutCursor 保存某个检测的进度
+func PutCursor(s Store, name string, c Cursor) error {
+	return s.Put(CursorBucket, name, c)
+}
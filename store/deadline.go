@@ -0,0 +1,45 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+const DeadlineBucket = "deadline"
+
+// Deadline 记录了某个 issue 在某个 Job 中的截止时间
+// 截止时间在 issue 第一次被 Job 检测到时，根据 label 的添加时间计算得出
+// 之后可以通过带有 delay 的指令延长
+type Deadline struct {
+	Job         string `json:"job"`
+	IssueNumber int    `json:"issueNumber"`
+	// issue 进入该状态（被添加 label）的时间
+	// 如果 label 被移除后再次添加，则视为新的一轮，重新计算截止时间
+	Since time.Time `json:"since"`
+	At    time.Time `json:"at"`
+	// 已延期的次数
+	Delayed int `json:"delayed"`
+	// 最近一次提醒所处的阶段（距离截止时间的天数）及时间
+	RemindStage  int       `json:"remindStage"`
+	LastRemindAt time.Time `json:"lastRemindAt"`
+}
+
+func deadlineKey(job string, number int) string {
+	return fmt.Sprintf("%s/%d", job, number)
+}
+
+// GetDeadline 获取 issue 在某个 Job 中的截止时间
+func GetDeadline(s Store, job string, number int) (d Deadline, ok bool, err error) {
+	ok, err = s.Get(DeadlineBucket, deadlineKey(job, number), &d)
+	return
+}
+
+// PutDeadline 保存 issue 在某个 Job 中的截止时间
+func PutDeadline(s Store, d Deadline) error {
+	return s.Put(DeadlineBucket, deadlineKey(d.Job, d.IssueNumber), d)
+}
+
+// DeleteDeadline 删除 issue 在某个 Job 中的截止时间
+func DeleteDeadline(s Store, job string, number int) error {
+	return s.Delete(DeadlineBucket, deadlineKey(job, number))
+}
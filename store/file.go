@@ -0,0 +1,146 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// 基于 JSON 的存储，数据保存在内存中，每次写入都通过 save 保存全部数据
+// 如本地 JSON 文件、issue body，适用于数据量不大的场景
+type fileStore struct {
+	// 保存全部数据，为空时仅保存在内存中
+	save func(content []byte) error
+	lock sync.Mutex
+	data map[string]map[string]json.RawMessage
+}
+
+// OpenFile 打开一个基于本地 JSON 文件的存储，文件不存在时会自动创建
+func OpenFile(path string) (Store, error) {
+	s := &fileStore{
+		save: func(content []byte) error {
+			return writeFile(path, content)
+		},
+		data: make(map[string]map[string]json.RawMessage),
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if len(content) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(content, &s.data); err != nil {
+		return nil, fmt.Errorf("parse store file %s: %v", path, err)
+	}
+	return s, nil
+}
+
+func (s *fileStore) Get(bucket, key string, value interface{}) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	raw, ok := s.data[bucket][key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, value)
+}
+
+func (s *fileStore) Put(bucket, key string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.data[bucket] == nil {
+		s.data[bucket] = make(map[string]json.RawMessage)
+	}
+	s.data[bucket][key] = raw
+	return s.flush()
+}
+
+func (s *fileStore) Delete(bucket, key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.data[bucket][key]; !ok {
+		return nil
+	}
+	delete(s.data[bucket], key)
+	return s.flush()
+}
+
+func (s *fileStore) Keys(bucket string) ([]string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	keys := make([]string, 0, len(s.data[bucket]))
+	for k := range s.data[bucket] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *fileStore) Buckets() ([]string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	buckets := make([]string, 0, len(s.data))
+	for k := range s.data {
+		buckets = append(buckets, k)
+	}
+	sort.Strings(buckets)
+	return buckets, nil
+}
+
+// NewMemory 创建一个仅保存在内存中的存储，进程退出后数据丢失
+func NewMemory() Store {
+	return &fileStore{data: make(map[string]map[string]json.RawMessage)}
+}
+
+// 每次写入都已保存至文件，无需额外处理
+func (s *fileStore) Close() error {
+	return nil
+}
+
+// 保存全部数据，仅保存在内存中的存储无需处理
+func (s *fileStore) flush() error {
+	if s.save == nil {
+		return nil
+	}
+	content, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.save(content)
+}
+
+// 先写入临时文件，再重命名，避免写入中途失败导致文件损坏
+func writeFile(path string, content []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,19 @@
+package store
+
+const IndexBucket = "index"
+
+// GetIssueIndex 获取上游文件对应的 issue number
+func GetIssueIndex(s Store, file string) (number int, ok bool, err error) {
+	ok, err = s.Get(IndexBucket, file, &number)
+	return
+}
+
+// PutIssueIndex 保存上游文件对应的 issue number
+func PutIssueIndex(s Store, file string, number int) error {
+	return s.Put(IndexBucket, file, number)
+}
+
+// DeleteIssueIndex 删除上游文件对应的 issue number
+func DeleteIssueIndex(s Store, file string) error {
+	return s.Delete(IndexBucket, file)
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// issue body 中存储数据的起止标记，标记之外的内容（如 pr issue 的检测进度）不会被修改
+const (
+	issueBegin = "<!-- issue-man store begin -->"
+	issueEnd   = "<!-- issue-man store end -->"
+)
+
+// GitHub issue body 的最大长度
+const issueBodyLimit = 65536
+
+// Issue 读取和修改保存存储数据的 issue 的 body
+// 由调用方基于 GitHub API 实现，以免 store 依赖 tools
+type Issue interface {
+	Body() (string, error)
+	EditBody(body string) error
+}
+
+// OpenIssue 打开一个保存在 issue body 中的存储，用于没有可写磁盘的部署，兼容旧版本通过 pr issue 保存进度的方式
+// 数据以 JSON 格式保存在 body 末尾的标记之间，每次写入都会重新读取 body，只替换标记之间的内容
+// 每次写入都会调用 API，且 body 的长度有限制，适用于数据量不大的场景
+func OpenIssue(issue Issue) (Store, error) {
+	body, err := issue.Body()
+	if err != nil {
+		return nil, err
+	}
+	s := &fileStore{
+		save: func(content []byte) error {
+			body, err := issue.Body()
+			if err != nil {
+				return err
+			}
+			body = WithIssueSection(body, string(content))
+			if len(body) > issueBodyLimit {
+				return fmt.Errorf("store data exceeds the limit of issue body (%d characters)", issueBodyLimit)
+			}
+			return issue.EditBody(body)
+		},
+		data: make(map[string]map[string]json.RawMessage),
+	}
+	if content := IssueSection(body); content != "" {
+		if err := json.Unmarshal([]byte(content), &s.data); err != nil {
+			return nil, fmt.Errorf("parse store in issue body: %v", err)
+		}
+	}
+	return s, nil
+}
+
+// IssueSection 返回 issue body 中保存的存储数据，不存在时返回空字符串
+func IssueSection(body string) string {
+	begin := strings.Index(body, issueBegin)
+	end := strings.Index(body, issueEnd)
+	if begin < 0 || end < begin {
+		return ""
+	}
+	content := strings.TrimSpace(body[begin+len(issueBegin) : end])
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimSuffix(content, "```")
+	return strings.TrimSpace(content)
+}
+
+// WithoutIssueSection 去除 issue body 中保存的存储数据，用于解析 body 中的其它内容
+func WithoutIssueSection(body string) string {
+	begin := strings.Index(body, issueBegin)
+	end := strings.Index(body, issueEnd)
+	if begin < 0 || end < begin {
+		return body
+	}
+	before, after := strings.TrimRight(body[:begin], "\r\n"), strings.TrimLeft(body[end+len(issueEnd):], "\r\n")
+	if before != "" && after != "" {
+		return before + "\n\n" + after
+	}
+	return before + after
+}
+
+// WithIssueSection 将存储数据写入 issue body 的末尾，替换已有的数据，content 为空时只去除已有的数据
+func WithIssueSection(body, content string) string {
+	body = strings.TrimRight(WithoutIssueSection(body), "\r\n")
+	if content == "" {
+		return body
+	}
+	section := fmt.Sprintf("%s\n```json\n%s\n```\n%s\n", issueBegin, content, issueEnd)
+	if body == "" {
+		return section
+	}
+	return body + "\n\n" + section
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"strings"
+	"testing"
+)
+
+// 保存在内存中的 issue body
+type fakeIssue struct {
+	body  string
+	edits int
+}
+
+func (i *fakeIssue) Body() (string, error) {
+	return i.body, nil
+}
+
+func (i *fakeIssue) EditBody(body string) error {
+	i.body = body
+	i.edits++
+	return nil
+}
+
+func TestOpenIssue(t *testing.T) {
+	checkpoint := "https://github.com/o/r/pull/1\n\nhttps://github.com/o/r/tree/abc"
+	issue := &fakeIssue{body: checkpoint}
+	s, err := OpenIssue(issue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(IndexBucket, "a.md", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := PutIssueIndex(s, "b.md", 2); err != nil {
+		t.Fatal(err)
+	}
+	if issue.edits != 2 || !strings.HasPrefix(issue.body, checkpoint+"\n\n"+issueBegin) {
+		t.Fatalf("body = %q", issue.body)
+	}
+	// 标记之外的内容保持不变
+	if got := WithoutIssueSection(issue.body); got != checkpoint {
+		t.Errorf("WithoutIssueSection() = %q", got)
+	}
+
+	// 重新打开时读取已保存的数据
+	reopened, err := OpenIssue(issue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	number, ok, err := GetIssueIndex(reopened, "b.md")
+	if err != nil || !ok || number != 2 {
+		t.Errorf("GetIssueIndex() = %d, %v, %v", number, ok, err)
+	}
+
+	// 其它内容被修改后，写入时保留修改
+	issue.body = strings.Replace(issue.body, "abc", "def", 1)
+	if err := reopened.Delete(IndexBucket, "a.md"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(issue.body, "tree/def") || strings.Contains(IssueSection(issue.body), "a.md") {
+		t.Errorf("body = %q", issue.body)
+	}
+}
+
+func TestWithIssueSection(t *testing.T) {
+	body := WithIssueSection("", "{}")
+	if IssueSection(body) != "{}" || WithoutIssueSection(body) != "" {
+		t.Errorf("WithIssueSection() = %q", body)
+	}
+	if got := WithIssueSection(WithIssueSection("text", "{}"), ""); got != "text" {
+		t.Errorf("WithIssueSection() = %q", got)
+	}
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"sort"
+)
+
+const (
+	MetaBucket = "meta"
+	versionKey = "version"
+)
+
+// Migration 一次存储结构的升级
+// Version 从 1 开始递增，已执行过的 Migration 不会再次执行
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(s Store) error
+}
+
+// Version 获取存储当前的版本，从未执行过 Migration 时为 0
+func Version(s Store) (version int, err error) {
+	_, err = s.Get(MetaBucket, versionKey, &version)
+	return
+}
+
+// Migrate 按版本号依次执行尚未执行的 Migration，返回本次执行的 Migration
+// 每执行成功一个就保存一次版本号，失败时停止，下次从失败的 Migration 继续执行
+func Migrate(s Store, migrations []Migration) (applied []Migration, err error) {
+	applied = make([]Migration, 0)
+	current, err := Version(s)
+	if err != nil {
+		return applied, err
+	}
+
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	for _, m := range sorted {
+		if m.Version <= current {
+			continue
+		}
+		if err := m.Up(s); err != nil {
+			return applied, err
+		}
+		if err := s.Put(MetaBucket, versionKey, m.Version); err != nil {
+			return applied, err
+		}
+		current = m.Version
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_Migrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := OpenBolt(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	ran := make([]int, 0)
+	migration := func(version int, fail bool) Migration {
+		return Migration{Version: version, Up: func(s Store) error {
+			if fail {
+				return fmt.Errorf("migration %d failed", version)
+			}
+			ran = append(ran, version)
+			return nil
+		}}
+	}
+
+	// 失败时停止，并保留已执行的版本
+	if _, err := Migrate(s, []Migration{migration(2, true), migration(1, false)}); err == nil {
+		t.Fatal("Migrate() want error")
+	}
+	if v, _ := Version(s); v != 1 {
+		t.Errorf("Version() = %d, want 1", v)
+	}
+	// 已执行的不再执行
+	applied, err := Migrate(s, []Migration{migration(1, false), migration(2, false), migration(3, false)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 {
+		t.Errorf("Migrate() applied %d, want 2", len(applied))
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran = %v, want %v", ran, want)
+	}
+}
+
+func Test_Copy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src, err := OpenFile(filepath.Join(dir, "test.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = PutIssueIndex(src, "docs/a.md", 12)
+	_ = PutCursor(src, DetectionCursor, Cursor{PRNumber: 3, SHA: "abc"})
+
+	dst, err := OpenBolt(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+	if err := Copy(dst, src); err != nil {
+		t.Fatal(err)
+	}
+
+	if number, ok, _ := GetIssueIndex(dst, "docs/a.md"); !ok || number != 12 {
+		t.Errorf("GetIssueIndex() = %d, %t, want 12, true", number, ok)
+	}
+	if c, ok, _ := GetCursor(dst, DetectionCursor); !ok || c.SHA != "abc" || c.PRNumber != 3 {
+		t.Errorf("GetCursor() = %+v, %t", c, ok)
+	}
+	buckets, _ := dst.Buckets()
+	if want := []string{CursorBucket, IndexBucket}; !reflect.DeepEqual(buckets, want) {
+		t.Errorf("Buckets() = %v, want %v", buckets, want)
+	}
+}
@@ -0,0 +1,144 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	bolt "go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 支持的存储类型
+const (
+	BackendBolt = "bolt"
+	BackendFile = "file"
+	// 保存在 issue body 中，见 OpenIssue
+	BackendIssue = "issue"
+)
+
+// 各存储类型默认的文件路径
+const (
+	DefaultBoltPath = "./issue-man.db"
+	DefaultFilePath = "./issue-man.json"
+)
+
+// ErrLocked 存储文件已被其它进程（一般是 start 启动的服务）打开
+var ErrLocked = errors.New("locked by another issue-man process")
+
+// Open 根据存储类型打开存储，path 为空时使用默认路径
+// 未指定类型时，.json 结尾的文件使用 JSON 文件存储（兼容旧版本的配置），其它使用 BoltDB
+// 第一次使用 BoltDB 时，如果存在旧版本默认路径下的 JSON 文件存储，会导入其中的数据
+func Open(backend, path string) (Store, error) {
+	if backend == "" && filepath.Ext(path) == ".json" {
+		backend = BackendFile
+	}
+	switch backend {
+	case BackendFile:
+		if path == "" {
+			path = DefaultFilePath
+		}
+		return OpenFile(path)
+	case "", BackendBolt:
+		if path == "" {
+			path = DefaultBoltPath
+		}
+		_, err := os.Stat(path)
+		fresh := os.IsNotExist(err)
+		s, err := OpenBolt(path)
+		if err == bolt.ErrTimeout {
+			return nil, fmt.Errorf("open %s: %w", path, ErrLocked)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(DefaultFilePath); !fresh || err != nil {
+			return s, nil
+		}
+		legacy, err := OpenFile(DefaultFilePath)
+		if err != nil {
+			_ = s.Close()
+			return nil, err
+		}
+		if err := Copy(s, legacy); err != nil {
+			_ = s.Close()
+			return nil, fmt.Errorf("import %s: %v", DefaultFilePath, err)
+		}
+		return s, nil
+	case BackendIssue:
+		return nil, fmt.Errorf("store backend %s requires an issue, use OpenIssue", backend)
+	default:
+		return nil, fmt.Errorf("unknown store backend %q, want %s, %s or %s", backend, BackendBolt, BackendFile, BackendIssue)
+	}
+}
+
+// OpenSnapshot 将存储中的数据读取至内存，用于只读的子命令，写入的数据不会保存
+// 存储文件被其它进程打开时返回 ErrLocked，此时可以使用 NewMemory
+func OpenSnapshot(backend, path string) (Store, error) {
+	if backend == "" && filepath.Ext(path) == ".json" {
+		backend = BackendFile
+	}
+	var src Store
+	switch backend {
+	case BackendFile:
+		if path == "" {
+			path = DefaultFilePath
+		}
+		s, err := OpenFile(path)
+		if err != nil {
+			return nil, err
+		}
+		src = s
+	case "", BackendBolt:
+		if path == "" {
+			path = DefaultBoltPath
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return NewMemory(), nil
+		}
+		db, err := bolt.Open(path, 0600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+		if err == bolt.ErrTimeout {
+			return nil, fmt.Errorf("open %s: %w", path, ErrLocked)
+		}
+		if err != nil {
+			return nil, err
+		}
+		src = &boltStore{db: db}
+	case BackendIssue:
+		return nil, fmt.Errorf("store backend %s requires an issue, use OpenIssue", backend)
+	default:
+		return nil, fmt.Errorf("unknown store backend %q, want %s, %s or %s", backend, BackendBolt, BackendFile, BackendIssue)
+	}
+	defer func() { _ = src.Close() }()
+
+	s := NewMemory()
+	if err := Copy(s, src); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Copy 将 src 中的全部数据写入 dst，dst 中已存在的 key 会被覆盖
+func Copy(dst, src Store) error {
+	buckets, err := src.Buckets()
+	if err != nil {
+		return err
+	}
+	for _, bucket := range buckets {
+		keys, err := src.Keys(bucket)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			var raw json.RawMessage
+			if _, err := src.Get(bucket, key, &raw); err != nil {
+				return err
+			}
+			if err := dst.Put(bucket, key, raw); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
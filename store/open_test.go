@@ -0,0 +1,54 @@
+package store
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.db")
+	s, err := Open(BackendBolt, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("bucket", "key", 1); err != nil {
+		t.Fatal(err)
+	}
+	// 被其它进程占用时返回 ErrLocked
+	if _, err := OpenSnapshot(BackendBolt, path); !errors.Is(err, ErrLocked) {
+		t.Fatalf("OpenSnapshot() err = %v, want ErrLocked", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := OpenSnapshot(BackendBolt, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v int
+	if ok, err := snapshot.Get("bucket", "key", &v); err != nil || !ok || v != 1 {
+		t.Fatalf("snapshot Get() = %d, %v, %v, want 1", v, ok, err)
+	}
+	// 写入快照的数据不会保存
+	if err := snapshot.Put("bucket", "key", 2); err != nil {
+		t.Fatal(err)
+	}
+	s, err = Open(BackendBolt, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+	if _, err := s.Get("bucket", "key", &v); err != nil || v != 1 {
+		t.Errorf("Get() = %d, %v, want 1", v, err)
+	}
+}
@@ -0,0 +1,17 @@
+package store
+
+import "time"
+
+const ScheduleBucket = "schedule"
+
+// GetLastRun 获取某个定时任务最近一次执行的时间
+// 从未执行过时，返回零值
+func GetLastRun(s Store, name string) (at time.Time, err error) {
+	_, err = s.Get(ScheduleBucket, name, &at)
+	return
+}
+
+// PutLastRun 保存某个定时任务最近一次执行的时间
+func PutLastRun(s Store, name string, at time.Time) error {
+	return s.Put(ScheduleBucket, name, at)
+}
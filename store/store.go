@@ -0,0 +1,21 @@
+// store 包提供了 issue-man 运行时状态的持久化存储
+// 例如 issue 的截止时间、同步检测的进度等，这些状态不适合保存在 issue 的 body 或 comment 中
+// 数据按 bucket 分类，每个 bucket 内是 key-value 结构，value 以 JSON 格式存储
+package store
+
+// Store 持久化存储的接口
+type Store interface {
+	// Get 读取 bucket 中 key 对应的值，并解析至 value
+	// 返回值 bool 表示 key 是否存在
+	Get(bucket, key string, value interface{}) (bool, error)
+	// Put 将 value 写入 bucket 中的 key
+	Put(bucket, key string, value interface{}) error
+	// Delete 删除 bucket 中的 key，key 不存在时不会返回错误
+	Delete(bucket, key string) error
+	// Keys 返回 bucket 中所有的 key
+	Keys(bucket string) ([]string, error)
+	// Buckets 返回所有的 bucket
+	Buckets() ([]string, error)
+	// Close 释放存储占用的资源，如 BoltDB 的文件锁
+	Close() error
+}
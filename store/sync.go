@@ -0,0 +1,95 @@
+package store
+
+import "time"
+
+const SyncBucket = "sync"
+
+// 同步检测的结果保存在 SyncBucket 中的 key
+const syncReportKey = "report"
+
+// 文件、pr 或 commit 的同步结果
+const (
+	SyncApplied = "applied"
+	SyncSkipped = "skipped"
+	SyncFailed  = "failed"
+	// 由于之前的 pr 或 commit 处理失败，本次未处理
+	SyncPending = "pending"
+	// dry-run 时，将要处理
+	SyncPlanned = "planned"
+)
+
+// SyncProgress 记录了一个 pr 或 commit 的处理进度
+// 处理失败时，记录已处理成功的文件，重试时跳过这些文件
+// 处理成功后记录为 Done，在保存检测进度之前中断时，下次检测可以跳过
+type SyncProgress struct {
+	SHA      string   `json:"sha"`
+	PRNumber int      `json:"prNumber"`
+	Done     bool     `json:"done"`
+	Applied  []string `json:"applied"`
+	// 处理失败的文件及原因
+	Failed    map[string]string `json:"failed,omitempty"`
+	Attempts  int               `json:"attempts"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+// GetSyncProgress 获取某个 pr 或 commit 的处理进度
+func GetSyncProgress(s Store, sha string) (p SyncProgress, ok bool, err error) {
+	ok, err = s.Get(SyncBucket, "unit/"+sha, &p)
+	return
+}
+
+// PutSyncProgress 保存某个 pr 或 commit 的处理进度
+func PutSyncProgress(s Store, p SyncProgress) error {
+	return s.Put(SyncBucket, "unit/"+p.SHA, p)
+}
+
+// DeleteSyncProgress 删除某个 pr 或 commit 的处理进度
+func DeleteSyncProgress(s Store, sha string) error {
+	return s.Delete(SyncBucket, "unit/"+sha)
+}
+
+// SyncReport 一次同步检测中，某个分支的检测结果
+type SyncReport struct {
+	Branch     string           `json:"branch"`
+	Mode       string           `json:"mode"`
+	StartedAt  time.Time        `json:"startedAt"`
+	FinishedAt time.Time        `json:"finishedAt"`
+	From       Cursor           `json:"from"`
+	To         Cursor           `json:"to"`
+	Units      []SyncUnitReport `json:"units"`
+	// 未能开始处理时的原因，如获取进度失败
+	Error string `json:"error,omitempty"`
+}
+
+// SyncUnitReport 一个 pr 或 commit 的同步结果
+type SyncUnitReport struct {
+	PRNumber int              `json:"prNumber,omitempty"`
+	SHA      string           `json:"sha"`
+	Result   string           `json:"result"`
+	Files    []SyncFileReport `json:"files"`
+}
+
+// SyncFileReport 一个文件的同步结果
+type SyncFileReport struct {
+	Filename         string `json:"filename"`
+	PreviousFilename string `json:"previousFilename,omitempty"`
+	Status           string `json:"status"`
+	Result           string `json:"result"`
+	Issues           []int  `json:"issues,omitempty"`
+	Error            string `json:"error,omitempty"`
+	// 改动被忽略的原因，被忽略的改动不添加 label，也不 comment
+	Ignored string `json:"ignored,omitempty"`
+	// dry-run 时，将要对 issue 做出的改动
+	Plan []string `json:"plan,omitempty"`
+}
+
+// GetSyncReports 获取最近一次同步检测中各个分支的检测结果
+func GetSyncReports(s Store) (r []SyncReport, ok bool, err error) {
+	ok, err = s.Get(SyncBucket, syncReportKey, &r)
+	return
+}
+
+// PutSyncReports 保存最近一次同步检测中各个分支的检测结果
+func PutSyncReports(s Store, r []SyncReport) error {
+	return s.Put(SyncBucket, syncReportKey, r)
+}
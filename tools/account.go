@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-github/v30/github"
+	"issue-man/global"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// RateLimit
+// 获取 token 剩余的调用次数，以及 token 的权限（scopes）
+// scopes 来自响应头 X-OAuth-Scopes，fine-grained token 和 GitHub App 没有该响应头
+func (a accountFunctions) RateLimit() (rate github.Rate, scopes []string, err error) {
+	limits, resp, err := global.Client.RateLimits(context.TODO())
+	if err != nil {
+		global.Sugar.Errorw("get rate limit",
+			"call api", "failed",
+			"err", err.Error(),
+		)
+		return rate, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		global.Sugar.Errorw("get rate limit",
+			"call api", "unexpect status code",
+			"status", resp.Status,
+			"status code", resp.StatusCode,
+			"response", resp.Body,
+		)
+		return rate, nil, fmt.Errorf("get rate limit fail. status code:%d", resp.StatusCode)
+	}
+
+	scopes = make([]string, 0)
+	for _, v := range strings.Split(resp.Header.Get("X-OAuth-Scopes"), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			scopes = append(scopes, v)
+		}
+	}
+	core := limits.GetCore()
+	if core == nil {
+		global.Sugar.Errorw("get rate limit",
+			"call api", "failed",
+			"err", "no core rate limit in response",
+		)
+		return rate, scopes, fmt.Errorf("get rate limit fail. no core rate limit in response")
+	}
+	return *core, scopes, nil
+}
+
+// 机器人的 login，获取成功后缓存
+var (
+	loginLock sync.Mutex
+	login     string
+)
+
+// Login
+// 获取 token 对应的用户，即机器人的 login
+func (a accountFunctions) Login() (string, error) {
+	loginLock.Lock()
+	defer loginLock.Unlock()
+	if login != "" {
+		return login, nil
+	}
+
+	user, resp, err := global.Client.Users.Get(context.TODO(), "")
+	if err != nil {
+		global.Sugar.Errorw("get authenticated user",
+			"call api", "failed",
+			"err", err.Error(),
+		)
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		global.Sugar.Errorw("get authenticated user",
+			"call api", "unexpect status code",
+			"status", resp.Status,
+			"status code", resp.StatusCode,
+			"response", resp.Body,
+		)
+		return "", fmt.Errorf("get authenticated user fail. status code:%d", resp.StatusCode)
+	}
+	login = user.GetLogin()
+	return login, nil
+}
@@ -0,0 +1,219 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/go-github/v30/github"
+	"issue-man/global"
+	"net/http"
+	"time"
+)
+
+// ErrCommitNotFound 比较的 commit 不存在，如 force push 之后，之前的 commit 已不在源库中
+var ErrCommitNotFound = errors.New("commit not found")
+
+// Head
+// 获取源库分支最新的 commit sha
+func (c commitFunctions) Head(branch string) (string, error) {
+	b, resp, err := global.Client.Repositories.GetBranch(
+		context.TODO(),
+		global.Conf.Repository.Spec.Source.Owner,
+		global.Conf.Repository.Spec.Source.Repository,
+		branch,
+	)
+	if err != nil {
+		global.Sugar.Errorw("load branch",
+			"call api", "failed",
+			"branch", branch,
+			"err", err.Error(),
+		)
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		global.Sugar.Errorw("load branch",
+			"call api", "unexpect status code",
+			"status", resp.Status,
+			"status code", resp.StatusCode,
+			"response", resp.Body,
+		)
+		return "", fmt.Errorf("load branch fail. status code:%d", resp.StatusCode)
+	}
+	return b.GetCommit().GetSHA(), nil
+}
+
+// LastBefore
+// 获取 until 之前源库分支上最后一次改动 path 的 commit sha，找不到时返回空字符串
+func (c commitFunctions) LastBefore(path string, until time.Time) (string, error) {
+	commits, resp, err := global.Client.Repositories.ListCommits(
+		context.TODO(),
+		global.Conf.Repository.Spec.Source.Owner,
+		global.Conf.Repository.Spec.Source.Repository,
+		&github.CommitsListOptions{
+			SHA:         global.Conf.Repository.Spec.Source.Branch,
+			Path:        path,
+			Until:       until,
+			ListOptions: github.ListOptions{PerPage: 1},
+		},
+	)
+	if err != nil {
+		global.Sugar.Errorw("list commits",
+			"call api", "failed",
+			"path", path,
+			"err", err.Error(),
+		)
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		global.Sugar.Errorw("list commits",
+			"call api", "unexpect status code",
+			"status", resp.Status,
+			"status code", resp.StatusCode,
+			"response", resp.Body,
+		)
+		return "", fmt.Errorf("list commits fail. status code:%d", resp.StatusCode)
+	}
+	if len(commits) == 0 {
+		return "", nil
+	}
+	return commits[0].GetSHA(), nil
+}
+
+// Compare
+// 获取 base 之后到 head 之间的 commit 列表，按时间顺序排列，最后一个元素是最新的 commit
+// compare API 最多返回 250 个 commit，超出的部分需要以返回的最后一个 commit 为 base 再次获取
+func (c commitFunctions) Compare(base, head string) (commits []*github.RepositoryCommit, total int, err error) {
+	comparison, resp, err := global.Client.Repositories.CompareCommits(
+		context.TODO(),
+		global.Conf.Repository.Spec.Source.Owner,
+		global.Conf.Repository.Spec.Source.Repository,
+		base,
+		head,
+	)
+	if err != nil {
+		global.Sugar.Errorw("compare commits",
+			"call api", "failed",
+			"base", base,
+			"head", head,
+			"err", err.Error(),
+		)
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, 0, fmt.Errorf("compare %s...%s: %w", base, head, ErrCommitNotFound)
+		}
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		global.Sugar.Errorw("compare commits",
+			"call api", "unexpect status code",
+			"status", resp.Status,
+			"status code", resp.StatusCode,
+			"response", resp.Body,
+		)
+		return nil, 0, fmt.Errorf("compare commits fail. status code:%d", resp.StatusCode)
+	}
+	global.Sugar.Infow("compare commits",
+		"base", base,
+		"head", head,
+		"status", comparison.GetStatus(),
+		"total", comparison.GetTotalCommits(),
+		"len", len(comparison.Commits))
+	return comparison.Commits, comparison.GetTotalCommits(), nil
+}
+
+// Get
+// 获取单个 commit，包括改动的文件列表
+func (c commitFunctions) Get(sha string) (*github.RepositoryCommit, error) {
+	commit, resp, err := global.Client.Repositories.GetCommit(
+		context.TODO(),
+		global.Conf.Repository.Spec.Source.Owner,
+		global.Conf.Repository.Spec.Source.Repository,
+		sha,
+	)
+	if err != nil {
+		global.Sugar.Errorw("load commit",
+			"call api", "failed",
+			"sha", sha,
+			"err", err.Error(),
+		)
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		global.Sugar.Errorw("load commit",
+			"call api", "unexpect status code",
+			"status", resp.Status,
+			"status code", resp.StatusCode,
+			"response", resp.Body,
+		)
+		return nil, fmt.Errorf("load commit fail. status code:%d", resp.StatusCode)
+	}
+	return commit, nil
+}
+
+// PullRequest
+// 获取包含该 commit 的 merged pull request，找不到时返回 nil
+// 直接 push 的 commit 不属于任何 pr
+func (c commitFunctions) PullRequest(sha string) *github.PullRequest {
+	prs, resp, err := global.Client.PullRequests.ListPullRequestsWithCommit(
+		context.TODO(),
+		global.Conf.Repository.Spec.Source.Owner,
+		global.Conf.Repository.Spec.Source.Repository,
+		sha,
+		&github.PullRequestListOptions{State: "closed"},
+	)
+	if err != nil {
+		global.Sugar.Errorw("load commit pull requests",
+			"call api", "failed",
+			"sha", sha,
+			"err", err.Error(),
+		)
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		global.Sugar.Errorw("load commit pull requests",
+			"call api", "unexpect status code",
+			"status", resp.Status,
+			"status code", resp.StatusCode,
+			"response", resp.Body,
+		)
+		return nil
+	}
+	for _, v := range prs {
+		if v.MergedAt != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// Ancestor
+// 判断 sha 是否为 head 的祖先（或同一个 commit），即 sha 的改动是否已包含在 head 中
+func (c commitFunctions) Ancestor(sha, head string) (bool, error) {
+	comparison, resp, err := global.Client.Repositories.CompareCommits(
+		context.TODO(),
+		global.Conf.Repository.Spec.Source.Owner,
+		global.Conf.Repository.Spec.Source.Repository,
+		sha,
+		head,
+	)
+	if err != nil {
+		global.Sugar.Errorw("compare commits",
+			"call api", "failed",
+			"base", sha,
+			"head", head,
+			"err", err.Error(),
+		)
+		return false, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		global.Sugar.Errorw("compare commits",
+			"call api", "unexpect status code",
+			"status", resp.Status,
+			"status code", resp.StatusCode,
+			"response", resp.Body,
+		)
+		return false, fmt.Errorf("compare commits fail. status code:%d", resp.StatusCode)
+	}
+	// head 在 sha 之后（ahead）或二者相同（identical）时，sha 是 head 的祖先
+	status := comparison.GetStatus()
+	return status == "ahead" || status == "identical", nil
+}
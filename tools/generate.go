@@ -49,7 +49,8 @@ func (g generateFunctions) Title(filename string, include config.Include) *strin
 		return genTitleByFile(filename)
 	case include.GroupBy == Directory:
 		return genTitleByDirectory(filename)
-	case global.Conf.IssueCreate.Spec.GroupBy == File:
+	// 文件类型的分类依据，未配置时为 spec.groupBy
+	case global.Conf.IssueCreate.GroupByOf(filename) == File:
 		return genTitleByFile(filename)
 	default:
 		return genTitleByDirectory(filename)
@@ -90,9 +91,12 @@ func (g generateFunctions) URL(filePath string) (source, translate string) {
 func (g generateFunctions) Body(remove bool, file, oldBody string) (body *string, length int) {
 	// 构造 body
 	bf := bytes.Buffer{}
+	// issue body 使用项目的语言
+	repository := global.Conf.Repository
+	langs := repository.Languages("", nil)
 
 	// Requirement 必要信息
-	require := fmt.Sprintf("### Requirement\n\n翻译人员信息登录：%s\n\n翻译指南：%s\n\n",
+	require := fmt.Sprintf(repository.Message("body.requirement", langs...),
 		"https://baidu.com",
 		"https://baidu.com",
 	)
@@ -102,12 +106,12 @@ func (g generateFunctions) Body(remove bool, file, oldBody string) (body *string
 	sourceSiteURL, translateSiteURL := g.URL(file)
 
 	// 按文件分隔，此时直接构造 body
-	if global.Conf.IssueCreate.Spec.GroupBy == File {
+	if global.Conf.IssueCreate.GroupByOf(file) == File {
 		if remove {
 			return nil, 0
 		}
 		// Source URL
-		url := fmt.Sprintf("[envoyproxy.io/docs](%s/%s)", sourceSiteURL, strings.TrimSuffix(path.Base(file), ".rst.txt"))
+		url := fmt.Sprintf("[envoyproxy.io/docs](%s/%s)", sourceSiteURL, global.Conf.IssueCreate.PageName(file))
 		// Source FileCommitHistory
 		history := fmt.Sprintf("[envoyproxy/envoyproxy.github.io#FileCommitHistory](https://github.com/%s/%s/commits/%s/%s)\n\n",
 			global.Conf.Repository.Spec.Source.Owner,
@@ -124,10 +128,10 @@ func (g generateFunctions) Body(remove bool, file, oldBody string) (body *string
 			file)
 
 		// Source
-		bf.WriteString(fmt.Sprintf("## Source\n\nURL：%s\n\nHistory：%s\n\nFile：%s\n\n", url, history, filename))
+		bf.WriteString(fmt.Sprintf(repository.Message("body.file", langs...), repository.Message("body.source", langs...), url, history, filename))
 
 		// Translate URL
-		url = fmt.Sprintf("[cloudnative.to/envoy/docs](%s/%s)", translateSiteURL, strings.TrimSuffix(path.Base(file), ".rst.txt"))
+		url = fmt.Sprintf("[cloudnative.to/envoy/docs](%s/%s)", translateSiteURL, global.Conf.IssueCreate.PageName(file))
 
 		// Translate FileCommitHistory
 		history = fmt.Sprintf("[cloudnative/envoy#FileCommitHistory](https://github.com/%s/%s/commits/%s/%s)\n\n",
@@ -145,7 +149,8 @@ func (g generateFunctions) Body(remove bool, file, oldBody string) (body *string
 			file)
 
 		// Translate
-		bf.WriteString(fmt.Sprintf("## Translate\n\nURL：%s\n\nHistory：%s\n\nFile：%s\n\n", url, history, filename))
+		bf.WriteString(fmt.Sprintf(repository.Message("body.file", langs...), repository.Message("body.translate", langs...), url, history, filename))
+		bf.WriteString(g.ignoredSection(oldBody))
 		return Get.String(bf.String()), 1
 	}
 
@@ -180,10 +185,10 @@ func (g generateFunctions) Body(remove bool, file, oldBody string) (body *string
 		global.Conf.Repository.Spec.Source.Branch,
 		path.Dir(file), // 目录
 	)
-	bf.WriteString(fmt.Sprintf("## Source\n\nURL：%s\n\nHistory：%s\n\n", url, history))
+	bf.WriteString(fmt.Sprintf(repository.Message("body.directory", langs...), repository.Message("body.source", langs...), url, history))
 
 	// Source FILES
-	bf.WriteString("Files：\n")
+	bf.WriteString(repository.Message("body.files", langs...))
 	for _, v := range *fileSlice {
 		if v == "" {
 			continue
@@ -208,10 +213,11 @@ func (g generateFunctions) Body(remove bool, file, oldBody string) (body *string
 		global.Conf.Repository.Spec.Translate.Branch,
 		path.Dir(file), // 目录
 	)
-	bf.WriteString(fmt.Sprintf("\n## Translate\n\nURL：%s\n\nHistory：%s\n\n", url, history))
+	bf.WriteString("\n")
+	bf.WriteString(fmt.Sprintf(repository.Message("body.directory", langs...), repository.Message("body.translate", langs...), url, history))
 
 	// Translate FILES
-	bf.WriteString("Files：\n")
+	bf.WriteString(repository.Message("body.files", langs...))
 	for _, v := range *fileSlice {
 		if v == "" {
 			continue
@@ -224,25 +230,90 @@ func (g generateFunctions) Body(remove bool, file, oldBody string) (body *string
 			global.Conf.Repository.Spec.Translate.Branch,
 			v))
 	}
+	if section := g.ignoredSection(oldBody); section != "" {
+		bf.WriteString("\n")
+		bf.WriteString(section)
+	}
 
 	return Get.String(bf.String()), len(*fileSlice)
 }
 
-// extractFilesFromBody 提取 body 内的文件列表
-// 要求每个文件一行，并且
-// 包含 global.Conf.IssueCreate.Spec.Prefix 关键字
-// 提取的内容为 Prefix 之后的内容
-// map 存储去重
+// 被忽略的上游改动在 issue body 中的标记，标记之后为改动记录，重新生成 body 时保留
+const ignoredMarker = "<!-- issue-man ignored -->"
+
+// IgnoredRecord 在 body 末尾记录被忽略的上游改动，每个改动一行：- `<sha>` <file>: <reason>
+// 已记录的改动不会重复记录
+func (g generateFunctions) IgnoredRecord(body, file, sha, reason string) string {
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	line := fmt.Sprintf("- `%s` %s: %s\n", sha, file, reason)
+	if strings.Contains(g.ignoredSection(body), line) {
+		return body
+	}
+	if !strings.Contains(body, ignoredMarker) {
+		repository := global.Conf.Repository
+		if body != "" && !strings.HasSuffix(body, "\n") {
+			body += "\n"
+		}
+		body += "\n" + ignoredMarker + "\n" + repository.Message("body.ignored", repository.Languages("", nil)...)
+	}
+	if !strings.HasSuffix(body, "\n") {
+		body += "\n"
+	}
+	return body + line
+}
+
+// ignoredSection 返回 body 中被忽略的改动记录，包括标记，不存在时返回空字符串
+func (g generateFunctions) ignoredSection(body string) string {
+	index := strings.Index(body, ignoredMarker)
+	if index < 0 {
+		return ""
+	}
+	section := body[index:]
+	if !strings.HasSuffix(section, "\n") {
+		section += "\n"
+	}
+	return section
+}
+
+// extractFilesFromBody 提取 body 内的文件列表，返回包含 prefix 的完整文件路径
+// 文件的格式由 Body() 决定，按目录分类时每个文件一行：- [<file>](<url>)，按文件分类时为 [<file>](<url>)
+// 兼容旧版本的格式：- https://github.com/<owner>/<repository>/tree/<branch>/<file>，以及去除了 prefix 的文件名
+// 仅保留支持的文件格式，map 存储去重
 func (g generateFunctions) extractFilesFromBody(body string) (files map[string]bool) {
 	files = make(map[string]bool)
+	// 被忽略的改动记录中的文件不属于文件列表
+	if index := strings.Index(body, ignoredMarker); index >= 0 {
+		body = body[:index]
+	}
 	lines := strings.Split(body, "\n")
 	prefix := global.Conf.IssueCreate.Spec.Prefix
+	add := func(file string) {
+		if global.Conf.IssueCreate.SupportType(file) {
+			files[file] = true
+		}
+	}
 	for _, line := range lines {
-		index := strings.Index(line, prefix)
-		if index > 0 {
-			// 去掉旧文件 prefix 及前面的内容（https://xxx.com/xxx/），后面会重新生成
-			line = line[index+len(prefix):]
-			files[line] = true
+		switch {
+		case strings.HasPrefix(line, "- ["):
+			file := strings.TrimPrefix(line, "- [")
+			if i := strings.IndexAny(file, "])"); i >= 0 {
+				file = file[:i]
+			}
+			if !strings.HasPrefix(file, prefix) {
+				file = path.Join(prefix, file)
+			}
+			add(file)
+		case strings.HasPrefix(line, "- ") && prefix != "" && strings.Index(line, prefix) > 0:
+			// 旧版本的格式，去掉 prefix 前面的内容（https://xxx.com/xxx/）
+			add(line[strings.Index(line, prefix):])
+		case prefix != "" && strings.Contains(line, "["+prefix):
+			file := line[strings.Index(line, "["+prefix)+1:]
+			if i := strings.IndexAny(file, "])"); i >= 0 {
+				file = file[:i]
+			}
+			add(file)
 		}
 	}
 	return
@@ -250,7 +321,7 @@ func (g generateFunctions) extractFilesFromBody(body string) (files map[string]b
 
 // BodyByPRNumberAndSha
 // 根据 pr Number 和 sha 生成 issue body
-// BodyByPRNumberAndSha() 有一个对应的解析方法 PRNumberFromBody()
+// BodyByPRNumberAndSha() 有对应的解析方法 PRNumberFromBody() 和 SHAFromBody()
 func (g generateFunctions) BodyByPRNumberAndSha(number int, sha string) *string {
 	body := fmt.Sprintf("https://github.com/%s/%s/pull/%d\n\nhttps://github.com/%s/%s/tree/%s",
 		global.Conf.Repository.Spec.Source.Owner,
@@ -270,6 +341,10 @@ func (g generateFunctions) NewIssue(include config.Include, filename string) (ne
 	new.Body, _ = Generate.Body(false, filename, "")
 
 	new.Labels = Convert.SliceAdd(&include.Labels, global.Conf.IssueCreate.Spec.Labels...)
+	// 文件类型的 label
+	if t, ok := global.Conf.IssueCreate.TypeOf(filename); ok {
+		new.Labels = Convert.SliceAdd(new.Labels, t.Labels...)
+	}
 	new.Assignees = Get.Strings(global.Conf.IssueCreate.Spec.Assignees)
 	new.Milestone = Get.Int(global.Conf.IssueCreate.Spec.Milestone)
 	return
@@ -3,6 +3,7 @@ package tools
 import (
 	"issue-man/config"
 	"issue-man/global"
+	"reflect"
 	"testing"
 )
 
@@ -50,3 +51,27 @@ func Test_genBody(t *testing.T) {
 		})
 	}
 }
+
+func Test_IgnoredRecord(t *testing.T) {
+	global.Conf = &config.Config{}
+	global.Conf.IssueCreate.Spec.Prefix = "content/en"
+	global.Conf.IssueCreate.Spec.FileType = []string{"md"}
+
+	generated, _ := Generate.Body(false, "content/en/docs/a.md", "")
+	body := Generate.IgnoredRecord(*generated, "content/en/docs/a.md", "0123456789abcdef", config.IgnoreWhitespace)
+	// 重复的记录
+	body = Generate.IgnoredRecord(body, "content/en/docs/a.md", "0123456789abcdef", config.IgnoreWhitespace)
+	// 重新生成 body 时保留记录
+	regenerated, length := Generate.Body(false, "content/en/docs/b.md", body)
+	body = Generate.IgnoredRecord(*regenerated, "content/en/docs/b.md", "fedcba9876543210", config.IgnoreMessage)
+
+	want := ignoredMarker + "\n## Ignored\n\n" +
+		"- `0123456` content/en/docs/a.md: whitespace\n" +
+		"- `fedcba9` content/en/docs/b.md: message\n"
+	if got := Generate.ignoredSection(body); got != want {
+		t.Errorf("ignoredSection() = %q, want %q", got, want)
+	}
+	if length != 2 || !reflect.DeepEqual(Parse.FilesFromBody(body), []string{"content/en/docs/a.md", "content/en/docs/b.md"}) {
+		t.Errorf("FilesFromBody() = %v, length %d", Parse.FilesFromBody(body), length)
+	}
+}
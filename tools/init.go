@@ -15,6 +15,8 @@ var (
 	PR       pullRequestFunctions
 	Tree     treeFunctions
 	Label    labelFunctions
+	Account  accountFunctions
+	Commit   commitFunctions
 )
 
 type (
@@ -48,4 +50,12 @@ type (
 	// 封装了仓库 label 相关的方法
 	// 主要是初始化时获取和创建 label
 	labelFunctions byte
+
+	// 封装了 token 相关的方法
+	// 主要是获取 token 的权限和剩余的调用次数
+	accountFunctions byte
+
+	// 封装了 commit 相关的方法
+	// 主要是按 commit 范围检测上游的变动
+	commitFunctions byte
 )
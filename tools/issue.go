@@ -6,7 +6,9 @@ import (
 	"github.com/google/go-github/v30/github"
 	"io/ioutil"
 	"issue-man/global"
+	"issue-man/store"
 	"net/http"
+	"time"
 )
 
 // 获取 workspace 下所有含有 kind/page 的 issue
@@ -16,7 +18,8 @@ func (i issueFunctions) GetAllMath() (issues map[string]*github.Issue, err error
 	workspace := global.Conf.Repository.Spec.Workspace
 
 	opt := &github.IssueListByRepoOptions{}
-	opt.State = "open"
+	// 包括已关闭的 issue，以便上游文件有变动时按 detection.closed 处理，避免创建相同 title 的 issue
+	opt.State = "all"
 	// 仅根据 kind/page 类型的 label 筛选 issue
 	opt.Labels = []string{"kind/page"}
 
@@ -51,6 +54,10 @@ func (i issueFunctions) GetAllMath() (issues map[string]*github.Issue, err error
 
 		for _, v := range is {
 			// TODO 关闭重复 issue
+			// 存在相同 title 的 issue 时，优先使用 open 的 issue
+			if exist, ok := issues[v.GetTitle()]; ok && exist.GetState() == "open" && v.GetState() != "open" {
+				continue
+			}
 			issues[v.GetTitle()] = v
 		}
 
@@ -118,9 +125,40 @@ func (i issueFunctions) Create(issue *github.IssueRequest) (newIssue *github.Iss
 		err = fmt.Errorf("response code: %d, body:%s", resp.StatusCode, string(body))
 		return
 	}
+	i.Index(newIssue)
 	return
 }
 
+// Index
+// 将 issue body 中的文件保存至文件与 issue 的索引
+// removed 为已从 issue 中移除的文件，仅当索引指向该 issue 时才会删除
+func (i issueFunctions) Index(issue *github.Issue, removed ...string) {
+	if issue == nil {
+		return
+	}
+	for _, file := range Parse.FilesFromBody(issue.GetBody()) {
+		if err := store.PutIssueIndex(global.Store, file, issue.GetNumber()); err != nil {
+			global.Sugar.Errorw("save issue index",
+				"status", "fail",
+				"file", file,
+				"err", err.Error())
+			return
+		}
+	}
+	for _, file := range removed {
+		number, ok, err := store.GetIssueIndex(global.Store, file)
+		if err == nil && ok && number == issue.GetNumber() {
+			err = store.DeleteIssueIndex(global.Store, file)
+		}
+		if err != nil {
+			global.Sugar.Errorw("delete issue index",
+				"status", "fail",
+				"file", file,
+				"err", err.Error())
+		}
+	}
+}
+
 func (i issueFunctions) Edit(issue *github.Issue) (updatedIssue *github.Issue, err error) {
 	return i.EditByIssueRequest(issue.GetNumber(), Convert.Issue(issue))
 }
@@ -204,6 +242,84 @@ func (i issueFunctions) Comment(number int, body string) {
 	}
 }
 
+// ListComments
+// 获取 issue 的全部 comment，按创建时间正序排列
+func (i issueFunctions) ListComments(number int) (comments []*github.IssueComment, err error) {
+	opt := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+
+	comments = make([]*github.IssueComment, 0)
+	for {
+		cs, resp, err := global.Client.Issues.ListComments(
+			context.TODO(),
+			global.Conf.Repository.Spec.Workspace.Owner,
+			global.Conf.Repository.Spec.Workspace.Repository,
+			number,
+			opt,
+		)
+		if err != nil {
+			global.Sugar.Errorw("list issue comments",
+				"call api", "failed",
+				"number", number,
+				"err", err.Error(),
+			)
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			global.Sugar.Errorw("list issue comments",
+				"call api", "unexpect status code",
+				"number", number,
+				"status", resp.Status,
+				"status code", resp.StatusCode,
+				"response", resp.Body,
+			)
+			return nil, fmt.Errorf("list issue comments fail. status code:%d", resp.StatusCode)
+		}
+		comments = append(comments, cs...)
+
+		if len(cs) < opt.PerPage {
+			break
+		}
+		opt.Page++
+	}
+	return comments, nil
+}
+
+// EditComment
+// 修改 issue comment 的内容
+func (i issueFunctions) EditComment(id int64, body string) error {
+	_, resp, err := global.Client.Issues.EditComment(
+		context.TODO(),
+		global.Conf.Repository.Spec.Workspace.Owner,
+		global.Conf.Repository.Spec.Workspace.Repository,
+		id,
+		&github.IssueComment{Body: &body},
+	)
+	if err != nil {
+		global.Sugar.Errorw("edit issue comment",
+			"call api", "failed",
+			"id", id,
+			"err", err.Error(),
+		)
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		global.Sugar.Errorw("edit issue comment",
+			"call api", "unexpect status code",
+			"id", id,
+			"status", resp.Status,
+			"status code", resp.StatusCode,
+			"response", resp.Body,
+		)
+		return fmt.Errorf("edit issue comment fail. status code:%d", resp.StatusCode)
+	}
+	return nil
+}
+
 // Get
 // 根据 number 获取一个 issue
 func (i issueFunctions) Get(number int) (*github.Issue, error) {
@@ -234,3 +350,104 @@ func (i issueFunctions) Get(number int) (*github.Issue, error) {
 	}
 	return issue, nil
 }
+
+// ListByLabels
+// 获取 workspace 下所有同时含有 labels 的 open issue
+func (i issueFunctions) ListByLabels(labels []string) (issues []*github.Issue, err error) {
+	opt := &github.IssueListByRepoOptions{}
+	opt.State = "open"
+	opt.Labels = labels
+	opt.Page = 1
+	opt.PerPage = 100
+
+	issues = make([]*github.Issue, 0)
+	for {
+		is, resp, err := global.Client.Issues.ListByRepo(
+			context.TODO(),
+			global.Conf.Repository.Spec.Workspace.Owner,
+			global.Conf.Repository.Spec.Workspace.Repository,
+			opt,
+		)
+		if err != nil {
+			global.Sugar.Errorw("list issues by labels",
+				"call api", "failed",
+				"labels", labels,
+				"err", err.Error(),
+			)
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			global.Sugar.Errorw("list issues by labels",
+				"call api", "unexpect status code",
+				"labels", labels,
+				"status", resp.Status,
+				"status code", resp.StatusCode,
+				"response", resp.Body,
+			)
+			return nil, fmt.Errorf("list issues by labels fail. status code:%d", resp.StatusCode)
+		}
+		issues = append(issues, is...)
+
+		if len(is) < opt.PerPage {
+			break
+		}
+		opt.Page++
+	}
+	return issues, nil
+}
+
+// ListEvents
+// 获取某个 issue 的全部 event，按时间正序排列
+func (i issueFunctions) ListEvents(number int) (events []*github.IssueEvent, err error) {
+	opt := &github.ListOptions{
+		Page:    1,
+		PerPage: 100,
+	}
+
+	events = make([]*github.IssueEvent, 0)
+	for {
+		es, resp, err := global.Client.Issues.ListIssueEvents(
+			context.TODO(),
+			global.Conf.Repository.Spec.Workspace.Owner,
+			global.Conf.Repository.Spec.Workspace.Repository,
+			number,
+			opt,
+		)
+		if err != nil {
+			global.Sugar.Errorw("list issue events",
+				"call api", "failed",
+				"number", number,
+				"err", err.Error(),
+			)
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			global.Sugar.Errorw("list issue events",
+				"call api", "unexpect status code",
+				"number", number,
+				"status", resp.Status,
+				"status code", resp.StatusCode,
+				"response", resp.Body,
+			)
+			return nil, fmt.Errorf("list issue events fail. status code:%d", resp.StatusCode)
+		}
+		events = append(events, es...)
+
+		if len(es) < opt.PerPage {
+			break
+		}
+		opt.Page++
+	}
+	return events, nil
+}
+
+// LabelAddedAt
+// 根据 issue events 判断 labels 最后一次被添加的时间
+// 如果有多个 label，则取其中最晚添加的那个 label 的时间，即 issue 同时拥有这些 label 的时间
+func (i issueFunctions) LabelAddedAt(number int, labels []string) (addedAt time.Time, err error) {
+	events, err := i.ListEvents(number)
+	if err != nil {
+		return addedAt, err
+	}
+	return Parse.LabelAddedAt(events, labels)
+}
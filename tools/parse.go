@@ -1,10 +1,13 @@
 package tools
 
 import (
+	"fmt"
+	"github.com/google/go-github/v30/github"
 	"issue-man/global"
 	"path"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // 解析指令
@@ -82,6 +85,34 @@ func (p parseFunctions) parseInstruct(s string) (is string, peoples []string) {
 	//return
 }
 
+// InstructArgs
+// 解析指令的参数，即指令名之后以空白分隔的全部内容（包含 @ 某人）
+// 返回值结构与 Instruct 相同，key 为指令名，value 为参数列表
+func (p parseFunctions) InstructArgs(body string) (args map[string][]string) {
+	args = make(map[string][]string)
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	for _, line := range strings.Split(body, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+			continue
+		}
+		is := strings.TrimPrefix(fields[0], "/")
+		if args[is] == nil {
+			args[is] = make([]string, 0)
+		}
+		args[is] = append(args[is], fields[1:]...)
+	}
+	return
+}
+
+// FilesFromBody
+// 从 issue body 中解析出文件列表，按文件名排序
+// 文件列表的格式由 Generate.Body() 决定
+func (p parseFunctions) FilesFromBody(body string) []string {
+	files := Generate.extractFilesFromBody(strings.ReplaceAll(body, "\r\n", "\n"))
+	return *Convert.MapToString(files)
+}
+
 // PRNumberFromBody
 // 从 body 内解析出 pr number
 // PRNumberFromBody() 有一个对应的生成方法 BodyByPRNumberAndSha()
@@ -99,3 +130,50 @@ func (p parseFunctions) PRNumberFromBody(body string) (number int) {
 	global.Sugar.Infow("parse pr number", "number", number)
 	return
 }
+
+// SHAFromBody
+// 从 body 内解析出上次处理的 commit sha，即最后一行链接的最后一段
+// SHAFromBody() 同样对应 BodyByPRNumberAndSha()
+func (p parseFunctions) SHAFromBody(body string) string {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n")), "\n")
+	if len(lines) < 2 {
+		return ""
+	}
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if !strings.Contains(last, "/tree/") {
+		return ""
+	}
+	return path.Base(last)
+}
+
+// LabelAddedAt
+// 从 issue events 中解析出 labels 最后一次被添加的时间
+// 要求 labels 中的每个 label 都有对应的 labeled 事件，且之后没有被移除
+func (p parseFunctions) LabelAddedAt(events []*github.IssueEvent, labels []string) (addedAt time.Time, err error) {
+	if len(labels) == 0 {
+		return addedAt, fmt.Errorf("labels can not be empty")
+	}
+	require := Convert.StringToMap(labels)
+	found := make(map[string]time.Time)
+	for _, v := range events {
+		if v.Label == nil || !require[v.Label.GetName()] {
+			continue
+		}
+		switch v.GetEvent() {
+		case "labeled":
+			found[v.Label.GetName()] = v.GetCreatedAt()
+		case "unlabeled":
+			delete(found, v.Label.GetName())
+		}
+	}
+	for _, label := range labels {
+		at, ok := found[label]
+		if !ok {
+			return addedAt, fmt.Errorf("label %s not found in events", label)
+		}
+		if at.After(addedAt) {
+			addedAt = at
+		}
+	}
+	return addedAt, nil
+}
@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"github.com/google/go-github/v30/github"
+	"issue-man/config"
+	"issue-man/global"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_LabelAddedAt(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2020, 5, d, 0, 0, 0, 0, time.UTC)
+	}
+	event := func(e, label string, at time.Time) *github.IssueEvent {
+		return &github.IssueEvent{
+			Event:     Get.String(e),
+			Label:     &github.Label{Name: Get.String(label)},
+			CreatedAt: &at,
+		}
+	}
+	events := []*github.IssueEvent{
+		event("labeled", "status/pending", day(1)),
+		event("labeled", "status/waiting-for-pr", day(2)),
+		event("unlabeled", "status/waiting-for-pr", day(3)),
+		event("labeled", "status/waiting-for-pr", day(4)),
+		event("labeled", "kind/page", day(5)),
+	}
+
+	tests := []struct {
+		name    string
+		labels  []string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "single", labels: []string{"status/pending"}, want: day(1)},
+		{name: "relabeled", labels: []string{"status/waiting-for-pr"}, want: day(4)},
+		{name: "latest of all", labels: []string{"status/pending", "kind/page"}, want: day(5)},
+		{name: "missing", labels: []string{"status/stale"}, wantErr: true},
+		{name: "empty", labels: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse.LabelAddedAt(events, tt.labels)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LabelAddedAt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("LabelAddedAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_SHAFromBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "generated", body: "https://github.com/o/r/pull/12\n\nhttps://github.com/o/r/tree/abc123", want: "abc123"},
+		{name: "crlf", body: "https://github.com/o/r/pull/12\r\n\r\nhttps://github.com/o/r/tree/abc123\r\n", want: "abc123"},
+		{name: "without pr", body: "https://github.com/o/r/pull/0\n\nhttps://github.com/o/r/tree/abc123", want: "abc123"},
+		{name: "pr only", body: "https://github.com/o/r/pull/12", want: ""},
+		{name: "empty", body: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse.SHAFromBody(tt.body); got != tt.want {
+				t.Errorf("SHAFromBody() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_FilesFromBody(t *testing.T) {
+	global.Conf = &config.Config{}
+	global.Conf.IssueCreate.Spec.Prefix = "content/en"
+	global.Conf.IssueCreate.Spec.FileType = []string{"md"}
+
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "directory",
+			body: "## Source\n\nFiles：\n- [content/en/docs/a.md](https://github.com/o/r/tree/master/content/en/docs/a.md)\n- [content/en/docs/b.md](https://github.com/o/r/tree/master/content/en/docs/b.md)\n\n## Translate\n\nFiles：\n- [content/en/docs/a.md](https://github.com/o/t/tree/master/content/en/docs/a.md)\n",
+			want: []string{"content/en/docs/a.md", "content/en/docs/b.md"},
+		},
+		{
+			name: "file",
+			body: "## Source\n\nURL：[site](https://site/a)\n\nHistory：[history](https://github.com/o/r/commits/master/content/en/docs/a.md)\n\nFile：[content/en/docs/a.md](https://github.com/o/r/tree/master/content/en/docs/a.md)\n\n",
+			want: []string{"content/en/docs/a.md"},
+		},
+		{
+			name: "legacy url",
+			body: "- https://github.com/o/r/tree/master/content/en/docs/a.md\n- https://github.com/o/t/tree/master/content/zh/docs/a.md\n",
+			want: []string{"content/en/docs/a.md"},
+		},
+		{
+			name: "legacy without prefix",
+			body: "- [/docs/a](https://github.com/o/r/tree/master/docs/a)\n- [/docs/a/x.md)](https://github.com/o/r/tree/master/docs/a/x.md)\n",
+			want: []string{"content/en/docs/a/x.md"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse.FilesFromBody(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilesFromBody() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
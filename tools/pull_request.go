@@ -2,16 +2,22 @@ package tools
 
 import (
 	"context"
+	"fmt"
 	"github.com/google/go-github/v30/github"
 	"issue-man/global"
 	"net/http"
+	"sort"
+	"time"
 )
 
-// 获取最近一次 merged 的 pull request
-func (i pullRequestFunctions) LatestMerged() (latestPR *github.PullRequest) {
+// 获取 branch 上最近一次 merged 的 pull request
+func (i pullRequestFunctions) LatestMerged(branch string) (latestPR *github.PullRequest) {
 	opt := &github.PullRequestListOptions{
-		State: "close",
-		Base:  global.Conf.Repository.Spec.Source.Branch,
+		State: "closed",
+		Base:  branch,
+		// 合并时会更新 updated_at，按 updated_at 倒序可以较快地找到最近合并的 pr
+		Sort:      "updated",
+		Direction: "desc",
 		ListOptions: github.ListOptions{
 			Page:    1,
 			PerPage: 30,
@@ -43,35 +49,76 @@ func (i pullRequestFunctions) LatestMerged() (latestPR *github.PullRequest) {
 		}
 		for _, v := range prs {
 			// 最近的一个 merged pr
-			if v.MergeCommitSHA != nil {
-				return v
+			if v.MergedAt == nil {
+				continue
+			}
+			if latestPR == nil || v.GetMergedAt().After(latestPR.GetMergedAt()) {
+				latestPR = v
 			}
 		}
+		// updated_at 不晚于 merged_at 的 pr 不可能更晚合并
+		if latestPR != nil && len(prs) > 0 && prs[len(prs)-1].GetUpdatedAt().Before(latestPR.GetMergedAt()) {
+			return latestPR
+		}
 		if len(prs) < opt.PerPage {
 			break
 		}
 		opt.Page++
 	}
-	return nil
+	return latestPR
 }
 
-// 获取从给定 pr number 到最近一次 pr 之间所有的 merged pr
-// 以及最近一次 merged pr 的 commit sha
-func (i pullRequestFunctions) ListRangePRs(prNumber int) (prs []*github.PullRequest) {
-	if prNumber == 0 {
-		return nil
+// 获取单个 pull request
+func (i pullRequestFunctions) Get(number int) (*github.PullRequest, error) {
+	pr, resp, err := global.Client.PullRequests.Get(
+		context.TODO(),
+		global.Conf.Repository.Spec.Source.Owner,
+		global.Conf.Repository.Spec.Source.Repository,
+		number,
+	)
+	if err != nil {
+		global.Sugar.Errorw("load pr",
+			"call api", "failed",
+			"number", number,
+			"err", err.Error(),
+		)
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		global.Sugar.Errorw("load pr",
+			"call api", "unexpect status code",
+			"status", resp.Status,
+			"status code", resp.StatusCode,
+			"response", resp.Body,
+		)
+		return nil, fmt.Errorf("load pr fail. status code:%d", resp.StatusCode)
+	}
+	return pr, nil
+}
+
+// 获取 branch 上在 since 及之后 merged 的 pr，按合并时间正序排列，最后一个元素是最近一个 pr
+// 与 since 同时合并的 pr 需要调用方根据 merge commit 判断是否已经处理过
+// 调用 API 失败时返回错误，不返回已获取的部分 pr，以免跳过未获取的 pr
+func (i pullRequestFunctions) ListRangePRs(branch string, since time.Time) (prs []*github.PullRequest, err error) {
+	if since.IsZero() {
+		return nil, nil
 	}
 	prs = make([]*github.PullRequest, 0)
-	// 逆序，先处理较早的 pr，最后处理最新的 pr
-	// 最后一个元素是最近一个 pr
 	defer func() {
-		Convert.ReversePR(prs)
-		global.Sugar.Infow("get valid pull requests", "len", len(prs))
+		if err != nil {
+			return
+		}
+		sort.SliceStable(prs, func(i, j int) bool {
+			return prs[i].GetMergedAt().Before(prs[j].GetMergedAt())
+		})
+		global.Sugar.Infow("get valid pull requests", "branch", branch, "len", len(prs))
 	}()
 
 	opt := &github.PullRequestListOptions{
-		State: "closed",
-		Base:  "master",
+		State:     "closed",
+		Base:      branch,
+		Sort:      "updated",
+		Direction: "desc",
 		ListOptions: github.ListOptions{
 			Page:    1,
 			PerPage: 100,
@@ -89,7 +136,7 @@ func (i pullRequestFunctions) ListRangePRs(prNumber int) (prs []*github.PullRequ
 				"call api", "failed",
 				"err", err.Error(),
 			)
-			return nil
+			return nil, err
 		}
 		if resp.StatusCode != http.StatusOK {
 			global.Sugar.Errorw("load pr list",
@@ -98,29 +145,32 @@ func (i pullRequestFunctions) ListRangePRs(prNumber int) (prs []*github.PullRequ
 				"status code", resp.StatusCode,
 				"response", resp.Body,
 			)
-			return nil
-		}
-		for _, v := range ps {
-			// 已遍历完需要检测的 pr
-			if v.GetNumber() <= prNumber {
-				return prs
-			}
-
-			// 仅处理 merged 的 pr
-			if v.MergeCommitSHA != nil {
-				prs = append(prs, v)
-				global.Sugar.Debugw("get valid pull request", "number", v.GetNumber())
-			}
-			// 避免极端情况下，需要在调用一次 API 的情况
-			if v.GetNumber()-1 <= prNumber {
-				return prs
-			}
+			return nil, fmt.Errorf("load pr list fail. status code:%d", resp.StatusCode)
 		}
-		// 不太可能出现
-		if len(ps) < 100 {
+		merged, done := mergedSince(ps, since)
+		prs = append(prs, merged...)
+		if done || len(ps) < opt.PerPage {
 			break
 		}
 		opt.Page++
 	}
-	return prs
+	return prs, nil
+}
+
+// 从按 updated_at 倒序排列的一页 pr 中，筛选出 since 之后（包括）合并的 pr
+// done 为 true 时，表示之后的 pr 都在 since 之前更新，无需继续获取
+func mergedSince(ps []*github.PullRequest, since time.Time) (prs []*github.PullRequest, done bool) {
+	prs = make([]*github.PullRequest, 0)
+	for _, v := range ps {
+		// 合并时会更新 updated_at，之后的 pr 都在 since 之前合并
+		if v.GetUpdatedAt().Before(since) {
+			return prs, true
+		}
+		// 仅处理 merged 的 pr
+		if v.MergedAt != nil && !v.GetMergedAt().Before(since) {
+			prs = append(prs, v)
+			global.Sugar.Debugw("get valid pull request", "number", v.GetNumber())
+		}
+	}
+	return prs, false
 }
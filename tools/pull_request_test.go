@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"github.com/google/go-github/v30/github"
+	"go.uber.org/zap"
+	"issue-man/global"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_mergedSince(t *testing.T) {
+	global.Sugar = zap.NewNop().Sugar()
+	since := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	pr := func(number int, updated time.Time, merged *time.Time) *github.PullRequest {
+		return &github.PullRequest{Number: &number, UpdatedAt: &updated, MergedAt: merged}
+	}
+	at := func(d time.Duration) time.Time { return since.Add(d) }
+	merged := func(d time.Duration) *time.Time { t := since.Add(d); return &t }
+
+	tests := []struct {
+		name     string
+		ps       []*github.PullRequest
+		want     []int
+		wantDone bool
+	}{
+		{
+			name: "cutoff by updated at",
+			ps:   []*github.PullRequest{pr(3, at(time.Hour), merged(time.Hour)), pr(2, at(-time.Hour), merged(-time.Hour)), pr(1, at(time.Hour), merged(time.Hour))},
+			want: []int{3}, wantDone: true,
+		},
+		{
+			name: "merged at since is included",
+			ps:   []*github.PullRequest{pr(2, at(time.Hour), merged(0))},
+			want: []int{2},
+		},
+		{
+			name: "updated after since but merged before",
+			ps:   []*github.PullRequest{pr(2, at(time.Hour), merged(-time.Hour))},
+			want: []int{},
+		},
+		{
+			name: "closed without merge",
+			ps:   []*github.PullRequest{pr(2, at(time.Hour), nil)},
+			want: []int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prs, done := mergedSince(tt.ps, since)
+			got := make([]int, 0, len(prs))
+			for _, v := range prs {
+				got = append(got, v.GetNumber())
+			}
+			if !reflect.DeepEqual(got, tt.want) || done != tt.wantDone {
+				t.Errorf("mergedSince() = %v, %v, want %v, %v", got, done, tt.want, tt.wantDone)
+			}
+		})
+	}
+}